@@ -0,0 +1,130 @@
+package apl
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/petergtz/alexa-wikipedia/wiki"
+	"github.com/petergtz/go-alexa"
+)
+
+const (
+	Interface     = "Alexa.Presentation.APL"
+	UserEventType = "Alexa.Presentation.APL.UserEvent"
+)
+
+//go:embed document.json
+var document []byte
+
+type RenderDocumentDirective struct {
+	Type        string                 `json:"type"`
+	Token       string                 `json:"token"`
+	Document    json.RawMessage        `json:"document"`
+	Datasources map[string]interface{} `json:"datasources"`
+}
+
+type Article struct {
+	Title              string     `json:"title"`
+	SectionTitle       string     `json:"sectionTitle"`
+	ImageURL           string     `json:"imageUrl"`
+	TextBeforeBodyPart string     `json:"textBeforeBodyPart"`
+	BodyPart           string     `json:"bodyPart"`
+	TextAfterBodyPart  string     `json:"textAfterBodyPart"`
+	TocTitle           string     `json:"tocTitle"`
+	Toc                []TocEntry `json:"toc"`
+}
+
+type TocEntry struct {
+	Number string `json:"number"`
+	Title  string `json:"title"`
+}
+
+// IsSupportedBy tells whether the device that sent the request can render APL documents.
+func IsSupportedBy(requestEnv *alexa.RequestEnvelope) bool {
+	return requestEnv.Context != nil &&
+		requestEnv.Context.System != nil &&
+		requestEnv.Context.System.Device != nil &&
+		requestEnv.Context.System.Device.SupportedInterfaces[Interface] != nil
+}
+
+// RenderArticle renders the page with the body part that is currently read highlighted within its section.
+// Touching an entry in the table of contents sends a UserEvent with the arguments "GoToSection" and the
+// section number.
+func RenderArticle(page wiki.Page, position int, positionWithinSectionBody int, bodyPart string, tocTitle string) *RenderDocumentDirective {
+	text := page.TextForPosition(position)
+	start := clamp(positionWithinSectionBody, 0, len(text))
+	end := clamp(start+len(bodyPart), start, len(text))
+	_, titlePath := page.SectionForPosition(position)
+	sectionTitle := ""
+	if len(titlePath) > 0 {
+		sectionTitle = titlePath[len(titlePath)-1]
+	}
+
+	article := Article{
+		Title:              page.Title,
+		SectionTitle:       sectionTitle,
+		ImageURL:           page.ImageURL,
+		TextBeforeBodyPart: text[:start],
+		BodyPart:           text[start:end],
+		TextAfterBodyPart:  text[end:],
+		TocTitle:           tocTitle,
+		Toc:                make([]TocEntry, len(page.Subsections)),
+	}
+	for i, section := range page.Subsections {
+		article.Toc[i] = TocEntry{Number: strconv.Itoa(i + 1), Title: section.Title}
+	}
+	return &RenderDocumentDirective{
+		Type:        "Alexa.Presentation.APL.RenderDocument",
+		Token:       "article",
+		Document:    document,
+		Datasources: map[string]interface{}{"payload": map[string]interface{}{"article": article}},
+	}
+}
+
+func clamp(i, min, max int) int {
+	if i < min {
+		return min
+	}
+	if i > max {
+		return max
+	}
+	return i
+}
+
+// DecodeRequestEnvelope decodes a request like json.Unmarshal would. go-alexa's Request has no field for the arguments
+// of UserEvent requests though. So for these, the arguments are kept in Request.Payload. Use Arguments to get them.
+func DecodeRequestEnvelope(data []byte, requestEnv *alexa.RequestEnvelope) error {
+	e := json.Unmarshal(data, requestEnv)
+	if e != nil {
+		return e
+	}
+	if requestEnv.Request == nil || requestEnv.Request.Type != UserEventType {
+		return nil
+	}
+	var userEvent struct {
+		Request struct {
+			Arguments json.RawMessage `json:"arguments"`
+		} `json:"request"`
+	}
+	e = json.Unmarshal(data, &userEvent)
+	if e != nil {
+		return e
+	}
+	requestEnv.Request.Payload = userEvent.Request.Arguments
+	return nil
+}
+
+// Arguments returns the arguments of a UserEvent request decoded with DecodeRequestEnvelope.
+func Arguments(request *alexa.Request) []string {
+	var arguments []interface{}
+	if json.Unmarshal(request.Payload, &arguments) != nil {
+		return nil
+	}
+	result := make([]string, len(arguments))
+	for i, argument := range arguments {
+		result[i] = fmt.Sprint(argument)
+	}
+	return result
+}
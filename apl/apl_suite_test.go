@@ -0,0 +1,13 @@
+package apl_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestApl(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Apl Suite")
+}
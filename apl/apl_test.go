@@ -0,0 +1,91 @@
+package apl_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/apl"
+	"github.com/petergtz/alexa-wikipedia/wiki"
+	"github.com/petergtz/go-alexa"
+)
+
+var _ = Describe("Apl", func() {
+	Describe("RenderArticle", func() {
+		It("highlights the body part within its section and lists top-level sections", func() {
+			page := wiki.Page{
+				Title:    "Main Title",
+				Body:     "Intro",
+				ImageURL: "https://upload.wikimedia.org/image.jpg",
+				Subsections: []wiki.Section{
+					{Number: "one", Title: "A", Body: "First part. Second part. Third part."},
+					{Number: "two", Title: "B", Body: "Body B"},
+				},
+			}
+
+			directive := apl.RenderArticle(page, 1, 15, "Second part. ", "Table of contents")
+
+			Expect(directive.Type).To(Equal("Alexa.Presentation.APL.RenderDocument"))
+			article := directive.Datasources["payload"].(map[string]interface{})["article"].(apl.Article)
+			Expect(article.Title).To(Equal("Main Title"))
+			Expect(article.SectionTitle).To(Equal("A"))
+			Expect(article.ImageURL).To(Equal("https://upload.wikimedia.org/image.jpg"))
+			Expect(article.TextBeforeBodyPart).To(Equal("A. First part. "))
+			Expect(article.BodyPart).To(Equal("Second part. "))
+			Expect(article.TextAfterBodyPart).To(Equal("Third part."))
+			Expect(article.Toc).To(Equal([]apl.TocEntry{{Number: "1", Title: "A"}, {Number: "2", Title: "B"}}))
+		})
+	})
+
+	Describe("IsSupportedBy", func() {
+		It("requires the APL interface", func() {
+			Expect(apl.IsSupportedBy(&alexa.RequestEnvelope{})).To(BeFalse())
+			Expect(apl.IsSupportedBy(&alexa.RequestEnvelope{Context: &alexa.Context{System: &alexa.System{Device: &alexa.Device{
+				SupportedInterfaces: map[string]interface{}{},
+			}}}})).To(BeFalse())
+			Expect(apl.IsSupportedBy(&alexa.RequestEnvelope{Context: &alexa.Context{System: &alexa.System{Device: &alexa.Device{
+				SupportedInterfaces: map[string]interface{}{"Alexa.Presentation.APL": map[string]interface{}{}},
+			}}}})).To(BeTrue())
+		})
+	})
+
+	Describe("DecodeRequestEnvelope", func() {
+		It("keeps the arguments of UserEvents", func() {
+			var requestEnv alexa.RequestEnvelope
+			e := apl.DecodeRequestEnvelope([]byte(`{
+				"session": {"attributes": {"word": "Baum"}},
+				"request": {"type": "Alexa.Presentation.APL.UserEvent", "arguments": ["GoToSection", 3]}
+			}`), &requestEnv)
+
+			Expect(e).NotTo(HaveOccurred())
+			Expect(requestEnv.Session.Attributes).To(HaveKeyWithValue("word", "Baum"))
+			Expect(apl.Arguments(requestEnv.Request)).To(Equal([]string{"GoToSection", "3"}))
+		})
+
+		It("leaves other requests alone", func() {
+			var requestEnv alexa.RequestEnvelope
+			e := apl.DecodeRequestEnvelope([]byte(`{"request": {"type": "IntentRequest"}}`), &requestEnv)
+
+			Expect(e).NotTo(HaveOccurred())
+			Expect(apl.Arguments(requestEnv.Request)).To(BeEmpty())
+		})
+	})
+
+	It("has a valid document", func() {
+		directive := apl.RenderArticle(wiki.Page{Title: "T", Body: "B"}, 0, 0, "T. B", "")
+		var document map[string]interface{}
+		Expect(json.Unmarshal(directive.Document, &document)).To(Succeed())
+		Expect(document).To(HaveKeyWithValue("type", "APL"))
+	})
+
+	It("imports the package defining the styles and resources the document uses", func() {
+		directive := apl.RenderArticle(wiki.Page{Title: "T", Body: "B"}, 0, 0, "T. B", "")
+		Expect(string(directive.Document)).To(ContainSubstring("textStyleBody"))
+		Expect(string(directive.Document)).To(ContainSubstring("@marginHorizontal"))
+		var document struct {
+			Import []struct{ Name string } `json:"import"`
+		}
+		Expect(json.Unmarshal(directive.Document, &document)).To(Succeed())
+		Expect(document.Import).To(ContainElement(HaveField("Name", "alexa-styles")))
+	})
+})
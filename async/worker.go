@@ -0,0 +1,101 @@
+// Package async runs work in the background without delaying responses.
+package async
+
+import (
+	"sync"
+
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"go.uber.org/zap"
+)
+
+// Worker runs jobs one after another in a single background goroutine. Enqueue never blocks: when the queue is full,
+// the job is dropped and counted as background_jobs_dropped instead, so slow jobs can't build up backpressure on the
+// request path.
+type Worker struct {
+	jobs    chan func()
+	metrics metrics.Metrics
+	logger  *zap.SugaredLogger
+	done    chan struct{}
+
+	mutex   sync.Mutex
+	idle    *sync.Cond
+	pending int
+	closed  bool
+}
+
+func NewWorker(queueSize int, metrics metrics.Metrics, logger *zap.SugaredLogger) *Worker {
+	w := &Worker{
+		jobs:    make(chan func(), queueSize),
+		metrics: metrics,
+		logger:  logger,
+		done:    make(chan struct{}),
+	}
+	w.idle = sync.NewCond(&w.mutex)
+	go w.run()
+	return w
+}
+
+func (w *Worker) run() {
+	defer close(w.done)
+	for job := range w.jobs {
+		w.runSafely(job)
+		w.mutex.Lock()
+		w.pending--
+		if w.pending == 0 {
+			w.idle.Broadcast()
+		}
+		w.mutex.Unlock()
+	}
+}
+
+func (w *Worker) runSafely(job func()) {
+	defer func() {
+		if e := recover(); e != nil {
+			w.logger.Errorw("Background job panicked", "error", e)
+		}
+	}()
+	job()
+}
+
+// Enqueue schedules job and returns true, or drops it and returns false if the queue is full or the worker is closed.
+func (w *Worker) Enqueue(job func()) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		w.drop("closed")
+		return false
+	}
+	select {
+	case w.jobs <- job:
+		w.pending++
+		return true
+	default:
+		w.drop("queue_full")
+		return false
+	}
+}
+
+func (w *Worker) drop(reason string) {
+	w.logger.Warnw("Dropped background job", "reason", reason)
+	w.metrics.Count("background_jobs_dropped", metrics.Dimensions{"reason": reason})
+}
+
+// Flush waits until all jobs enqueued so far have run.
+func (w *Worker) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for w.pending > 0 {
+		w.idle.Wait()
+	}
+}
+
+// Close stops accepting jobs and waits until the enqueued ones have run. Calling it more than once is fine.
+func (w *Worker) Close() {
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.jobs)
+	}
+	w.mutex.Unlock()
+	<-w.done
+}
@@ -0,0 +1,97 @@
+package async_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/async"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Worker", func() {
+	var (
+		worker   *async.Worker
+		recorder *metrics.Prometheus
+	)
+
+	BeforeEach(func() {
+		recorder = metrics.NewPrometheus(metrics.DefaultBuckets)
+		worker = async.NewWorker(2, recorder, zap.NewNop().Sugar())
+	})
+
+	AfterEach(func() {
+		worker.Close()
+	})
+
+	// blockWorker occupies the worker with a job until the returned function is called.
+	blockWorker := func() (release func()) {
+		started, released := make(chan struct{}), make(chan struct{})
+		Expect(worker.Enqueue(func() {
+			close(started)
+			<-released
+		})).To(BeTrue())
+		<-started
+		return func() { close(released) }
+	}
+
+	It("runs jobs in order", func() {
+		release := blockWorker()
+		var results []int
+		worker.Enqueue(func() { results = append(results, 1) })
+		worker.Enqueue(func() { results = append(results, 2) })
+		release()
+
+		worker.Flush()
+
+		Expect(results).To(Equal([]int{1, 2}))
+	})
+
+	It("drops jobs instead of blocking when the queue is full", func() {
+		release := blockWorker()
+		ran := 0
+		Expect(worker.Enqueue(func() { ran++ })).To(BeTrue())
+		Expect(worker.Enqueue(func() { ran++ })).To(BeTrue())
+
+		Expect(worker.Enqueue(func() { ran++ })).To(BeFalse())
+		Expect(recorder.Text()).To(ContainSubstring(`background_jobs_dropped_total{reason="queue_full"} 1` + "\n"))
+
+		release()
+		worker.Flush()
+		Expect(ran).To(Equal(2))
+	})
+
+	It("keeps running after a job panicked", func() {
+		worker.Enqueue(func() { panic("some error") })
+		ran := false
+		worker.Enqueue(func() { ran = true })
+
+		worker.Flush()
+
+		Expect(ran).To(BeTrue())
+	})
+
+	Describe("Close", func() {
+		It("runs the enqueued jobs before returning", func() {
+			release := blockWorker()
+			ran := false
+			worker.Enqueue(func() { ran = true })
+			release()
+
+			worker.Close()
+
+			Expect(ran).To(BeTrue())
+		})
+
+		It("makes the worker drop further jobs", func() {
+			worker.Close()
+
+			Expect(worker.Enqueue(func() {})).To(BeFalse())
+			Expect(recorder.Text()).To(ContainSubstring(`background_jobs_dropped_total{reason="closed"} 1` + "\n"))
+		})
+
+		It("can be called more than once", func() {
+			worker.Close()
+			worker.Close()
+		})
+	})
+})
@@ -16,3 +16,10 @@ func (c BodyChopper) MoveToNextBodyPart(body string, currentPosition int, curren
 func (c BodyChopper) FetchBodyPart(body string, currentPositionWithinSectionBody int) string {
 	return body[currentPositionWithinSectionBody:math.Min(len(body), currentPositionWithinSectionBody+c.MaxBodyPartLen)]
 }
+
+func (c BodyChopper) MoveToPreviousBodyPart(body string, currentPosition int, currentPositionWithinSectionBody int) (newPosition int, newPositionWithinSectionBody int) {
+	if currentPositionWithinSectionBody == 0 {
+		return math.MaxInt(currentPosition-1, 0), 0
+	}
+	return currentPosition, (currentPositionWithinSectionBody - 1) / c.MaxBodyPartLen * c.MaxBodyPartLen
+}
@@ -13,13 +13,14 @@ func TestDumb(t *testing.T) {
 	RunSpecs(t, "Dumb Suite")
 }
 
-var _ = Describe("Dumb", func() {
-	It("chops", func() {
-		body := `Ein Querschnitt durch einen Baumstamm, die verholzende Hauptachse.
+const body = `Ein Querschnitt durch einen Baumstamm, die verholzende Hauptachse.
 Die äußerste Schicht bildet die Baumrinde. Sie besteht aus der Bastschicht.
 Zwischen der Bastschicht und dem Holz befindet sich bei Gymnospermen.
 Hinsichtlich des inneren Baus des Baumstamms weichen die zu den Einkeimblättrigen ab.
 `
+
+var _ = Describe("Dumb", func() {
+	It("chops", func() {
 		c := dumb.BodyChopper{80}
 		position := 0
 		positionWithinBodyPart := 0
@@ -49,4 +50,20 @@ Hinsichtlich des inneren `))
 		Expect(position).To(Equal(1))
 		Expect(positionWithinBodyPart).To(Equal(0))
 	})
+
+	It("chops backwards", func() {
+		c := dumb.BodyChopper{80}
+
+		position, positionWithinBodyPart := c.MoveToPreviousBodyPart(body, 0, len(body))
+		Expect(position).To(Equal(0))
+		Expect(positionWithinBodyPart).To(Equal(240))
+
+		position, positionWithinBodyPart = c.MoveToPreviousBodyPart(body, position, positionWithinBodyPart)
+		Expect(position).To(Equal(0))
+		Expect(positionWithinBodyPart).To(Equal(160))
+
+		position, positionWithinBodyPart = c.MoveToPreviousBodyPart(body, 3, 0)
+		Expect(position).To(Equal(2))
+		Expect(positionWithinBodyPart).To(Equal(0))
+	})
 })
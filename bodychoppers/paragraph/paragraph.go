@@ -5,6 +5,8 @@ import "github.com/petergtz/alexa-wikipedia/wiki"
 type BodyChopper struct {
 	MaxBodyPartLen int
 	Fallback       wiki.BodyChopper
+	// OnFetchFallback, if set, is called whenever FetchBodyPart needs the Fallback, because a paragraph is too long.
+	OnFetchFallback func()
 }
 
 func (c BodyChopper) FetchBodyPart(body string, currentPositionWithinSectionBody int) string {
@@ -13,6 +15,9 @@ func (c BodyChopper) FetchBodyPart(body string, currentPositionWithinSectionBody
 	for index, runeValue := range body[currentPositionWithinSectionBody:] {
 		if index > c.MaxBodyPartLen {
 			if result == "" {
+				if c.OnFetchFallback != nil {
+					c.OnFetchFallback()
+				}
 				return c.Fallback.FetchBodyPart(body, currentPositionWithinSectionBody)
 			}
 			fitsIn = false
@@ -47,3 +52,21 @@ func (c BodyChopper) MoveToNextBodyPart(body string, currentPosition int, curren
 	}
 	return currentPosition, newPositionWithinSectionBody
 }
+
+func (c BodyChopper) MoveToPreviousBodyPart(body string, currentPosition int, currentPositionWithinSectionBody int) (newPosition int, newPositionWithinSectionBody int) {
+	if currentPositionWithinSectionBody == 0 {
+		if currentPosition == 0 {
+			return 0, 0
+		}
+		return currentPosition - 1, 0
+	}
+	// Body parts are only well-defined when chopping from the beginning of a body,
+	// so we walk forward until we pass the current position.
+	for {
+		position, positionWithinSectionBody := c.MoveToNextBodyPart(body, currentPosition, newPositionWithinSectionBody)
+		if position != currentPosition || positionWithinSectionBody >= currentPositionWithinSectionBody {
+			return currentPosition, newPositionWithinSectionBody
+		}
+		newPositionWithinSectionBody = positionWithinSectionBody
+	}
+}
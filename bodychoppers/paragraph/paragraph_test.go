@@ -92,6 +92,77 @@ var _ = Describe("Paragraph", func() {
 			Expect(position).To(Equal(1))
 			Expect(positionWithinBodyPart).To(Equal(0))
 		})
+
+		It("tells when it fetches using the fallback", func() {
+			fallbacks := 0
+			c := paragraph.BodyChopper{
+				MaxBodyPartLen:  80,
+				Fallback:        &dumb.BodyChopper{MaxBodyPartLen: 80},
+				OnFetchFallback: func() { fallbacks++ },
+			}
+
+			c.FetchBodyPart(body, 0)
+			Expect(fallbacks).To(Equal(0))
+
+			c.FetchBodyPart(body, 215)
+			Expect(fallbacks).To(Equal(1))
+		})
+	})
+
+	Context("Changing MaxBodyPart mid-body", func() {
+		It("continues right after the body part read with the previous length", func() {
+			long := paragraph.BodyChopper{MaxBodyPartLen: 160, Fallback: &dumb.BodyChopper{MaxBodyPartLen: 160}}
+			short := paragraph.BodyChopper{MaxBodyPartLen: 80, Fallback: &dumb.BodyChopper{MaxBodyPartLen: 80}}
+
+			Expect(long.FetchBodyPart(body, 0)).To(Equal(body[:145]))
+			position, positionWithinBodyPart := long.MoveToNextBodyPart(body, 0, 0)
+			Expect(position).To(Equal(0))
+			Expect(positionWithinBodyPart).To(Equal(145))
+
+			Expect(short.FetchBodyPart(body, positionWithinBodyPart)).To(Equal(`Zwischen der Bastschicht und dem Holz befindet sich bei Gymnospermen.` + "\n"))
+			position, positionWithinBodyPart = short.MoveToNextBodyPart(body, position, positionWithinBodyPart)
+			Expect(position).To(Equal(0))
+			Expect(positionWithinBodyPart).To(Equal(215))
+
+			position, positionWithinBodyPart = short.MoveToPreviousBodyPart(body, position, positionWithinBodyPart)
+			Expect(position).To(Equal(0))
+			Expect(positionWithinBodyPart).To(Equal(145))
+		})
+	})
+
+	Context("Moving backwards", func() {
+		It("moves to the start of the preceding body part", func() {
+			c := paragraph.BodyChopper{
+				MaxBodyPartLen: 80,
+				Fallback: &dumb.BodyChopper{
+					MaxBodyPartLen: 80,
+				},
+			}
+
+			position, positionWithinBodyPart := c.MoveToPreviousBodyPart(body, 0, len(body))
+			Expect(position).To(Equal(0))
+			Expect(positionWithinBodyPart).To(Equal(295))
+
+			position, positionWithinBodyPart = c.MoveToPreviousBodyPart(body, position, positionWithinBodyPart)
+			Expect(position).To(Equal(0))
+			Expect(positionWithinBodyPart).To(Equal(215))
+
+			position, positionWithinBodyPart = c.MoveToPreviousBodyPart(body, position, positionWithinBodyPart)
+			Expect(position).To(Equal(0))
+			Expect(positionWithinBodyPart).To(Equal(145))
+
+			position, positionWithinBodyPart = c.MoveToPreviousBodyPart(body, position, 67)
+			Expect(position).To(Equal(0))
+			Expect(positionWithinBodyPart).To(Equal(0))
+		})
+
+		It("moves to the previous position when at the start of a body", func() {
+			c := paragraph.BodyChopper{MaxBodyPartLen: 80, Fallback: &dumb.BodyChopper{MaxBodyPartLen: 80}}
+
+			position, positionWithinBodyPart := c.MoveToPreviousBodyPart(body, 2, 0)
+			Expect(position).To(Equal(1))
+			Expect(positionWithinBodyPart).To(Equal(0))
+		})
 	})
 
 	Context("MaxBodyPart 500", func() {
@@ -0,0 +1,119 @@
+// record-fixtures sends the requests in integ_tests/fixtures to the skill using the real Wikipedia, and records the
+// skill's responses as *.response.json next to them, and the Wikipedia traffic as cassettes in integ_tests/cassettes.
+// The integration tests then replay the cassettes. Pass fixture file names to only record those, e.g.:
+//
+//	go run ./cmd/record-fixtures LaunchRequest.de-DE.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/petergtz/alexa-wikipedia/cmd/skill/factory"
+	"github.com/petergtz/alexa-wikipedia/recording"
+	"github.com/petergtz/go-alexa"
+	"go.uber.org/zap"
+)
+
+// failureDetectingTransport remembers whether any request failed, so we don't record error responses of the skill
+// that are due to network problems.
+type failureDetectingTransport struct {
+	mutex  sync.Mutex
+	failed bool
+}
+
+func (t *failureDetectingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, e := http.DefaultTransport.RoundTrip(request)
+	if e != nil || response.StatusCode >= 400 {
+		t.mutex.Lock()
+		t.failed = true
+		t.mutex.Unlock()
+	}
+	return response, e
+}
+
+func main() {
+	fixturesDir := flag.String("fixtures", filepath.Join("integ_tests", "fixtures"), "directory containing the request fixtures")
+	cassettesDir := flag.String("cassettes", filepath.Join("integ_tests", "cassettes"), "directory to write cassettes to")
+	flag.Parse()
+
+	logger := zap.NewNop().Sugar()
+	fixtureNames := flag.Args()
+	if len(fixtureNames) == 0 {
+		paths, e := filepath.Glob(filepath.Join(*fixturesDir, "*.json"))
+		if e != nil {
+			panic(e)
+		}
+		for _, path := range paths {
+			if !strings.HasSuffix(path, ".response.json") {
+				fixtureNames = append(fixtureNames, filepath.Base(path))
+			}
+		}
+	}
+
+	failures := 0
+	for _, fixtureName := range fixtureNames {
+		e := record(fixtureName, *fixturesDir, *cassettesDir, logger)
+		if e != nil {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", fixtureName, e)
+			failures++
+			continue
+		}
+		fmt.Println("Recorded", fixtureName)
+	}
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
+
+func record(fixtureName string, fixturesDir string, cassettesDir string, logger *zap.SugaredLogger) error {
+	buf, e := os.ReadFile(filepath.Join(fixturesDir, fixtureName))
+	if e != nil {
+		return e
+	}
+	buf = bytes.Replace(buf, []byte("TIMESTAMP"), []byte(time.Now().UTC().Format("2006-01-02T15:04:05Z")), -1)
+	var requestEnv alexa.RequestEnvelope
+	e = json.Unmarshal(buf, &requestEnv)
+	if e != nil {
+		return e
+	}
+
+	cassette := filepath.Join(cassettesDir, fixtureName)
+	e = os.Remove(cassette)
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	config := factory.OfflineConfig()
+	config.WikipediaMode = factory.WikipediaModeRecord
+	config.WikipediaCassette = cassette
+	transport := &failureDetectingTransport{}
+	config.WikipediaTransport = transport
+	skill := factory.CreateSkillFrom(config, logger)
+	defer skill.Close()
+	response := skill.ProcessRequest(&requestEnv)
+	if transport.failed {
+		return fmt.Errorf("requests to Wikipedia failed. Not recording response")
+	}
+	// The recorder only writes the cassette on the first request. An empty cassette documents that the fixture needs
+	// no Wikipedia traffic.
+	if _, e = os.Stat(cassette); os.IsNotExist(e) {
+		e = (&recording.Cassette{Interactions: []recording.Interaction{}}).Save(cassette)
+	}
+	if e != nil {
+		return e
+	}
+
+	output, e := json.MarshalIndent(response, "", "    ")
+	if e != nil {
+		return e
+	}
+	return os.WriteFile(filepath.Join(fixturesDir, strings.TrimSuffix(fixtureName, ".json")+".response.json"), append(output, '\n'), 0644)
+}
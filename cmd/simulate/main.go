@@ -0,0 +1,130 @@
+// simulate lets you have a conversation with the skill on the command line. Type utterances as you would say them,
+// e.g. "öffne meine enzyklopädie" or "definiere Käsekuchen". Utterances are resolved to intents using the sample
+// utterances in models/<locale>.json. Lines starting with "/" are commands:
+//
+//	/launch          open the skill in a new session
+//	/end [reason]    end the session, e.g. with reason EXCEEDED_MAX_REPROMPTS; defaults to USER_INITIATED
+//	/quit            exit
+//
+// Articles are fetched from Wikipedia. Preferences are kept in memory, interactions are not logged.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/petergtz/alexa-wikipedia/cmd/skill/factory"
+	"github.com/petergtz/alexa-wikipedia/interactions"
+	"github.com/petergtz/alexa-wikipedia/mediawiki"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/simulator"
+	"github.com/petergtz/alexa-wikipedia/skill"
+	"github.com/petergtz/alexa-wikipedia/tracing"
+	"github.com/petergtz/go-alexa"
+	"go.uber.org/zap"
+)
+
+func main() {
+	locale := flag.String("locale", "de-DE", "locale of the conversation; models/<locale>.json must exist")
+	modelsDir := flag.String("models", "models", "directory containing the interaction models")
+	verbose := flag.Bool("verbose", false, "show the skill's logs")
+	trace := flag.Bool("trace", false, "write tracing spans to stderr")
+	flag.Parse()
+
+	logger := zap.NewNop().Sugar()
+	if *verbose {
+		logger = zap.NewExample().Sugar()
+	}
+	var tracer *tracing.Tracer
+	if *trace {
+		tracer = tracing.NewTracer(tracing.NewJSONExporter(os.Stderr))
+	}
+	model, e := simulator.LoadInteractionModel(*modelsDir, *locale)
+	if e != nil {
+		fmt.Fprintln(os.Stderr, "Could not load interaction model:", e)
+		os.Exit(1)
+	}
+	conversation := &simulator.Conversation{
+		Skill: skill.NewWikipediaSkill(
+			&mediawiki.MediaWiki{Logger: logger, WikiPagePreProcessor: mediawiki.NewTextQualityPipeline(&mediawiki.LoggingPersistence{Logger: logger}, logger)},
+			factory.CreateI18nBundle(),
+			interactions.NoOpStore{},
+			interactions.NoOpStore{},
+			preferences.NewInMemoryStore(),
+			nil,
+			metrics.NoOp{},
+			tracer,
+			logger,
+		),
+		Resolver: simulator.NewResolver(model, *locale),
+		Locale:   *locale,
+		UserID:   "simulated-user",
+	}
+
+	input := bufio.NewScanner(os.Stdin)
+	for prompt(conversation); input.Scan(); prompt(conversation) {
+		line := strings.TrimSpace(input.Text())
+		switch {
+		case line == "":
+		case line == "/quit":
+			return
+		case line == "/launch":
+			printResponse(os.Stdout, conversation.Launch())
+		case strings.HasPrefix(line, "/end"):
+			reason := strings.TrimSpace(strings.TrimPrefix(line, "/end"))
+			if reason == "" {
+				reason = "USER_INITIATED"
+			}
+			printResponse(os.Stdout, conversation.End(reason))
+		case strings.HasPrefix(line, "/"):
+			fmt.Println("Unknown command. Use /launch, /end [reason] or /quit.")
+		default:
+			if !conversation.Resolver.IsLaunch(line) {
+				intent, ok := conversation.Resolver.Resolve(line)
+				if !ok {
+					fmt.Println("(no sample matched)")
+				}
+				fmt.Printf("→ %v %v\n", intent.Name, slotValues(intent))
+			}
+			printResponse(os.Stdout, conversation.Say(line))
+		}
+	}
+}
+
+func prompt(conversation *simulator.Conversation) {
+	if conversation.InSession() {
+		fmt.Print("[in session] > ")
+	} else {
+		fmt.Print("> ")
+	}
+}
+
+func slotValues(intent alexa.Intent) string {
+	var values []string
+	for name, slot := range intent.Slots {
+		values = append(values, name+"="+slot.Value)
+	}
+	return strings.Join(values, " ")
+}
+
+func printResponse(w io.Writer, response *alexa.ResponseEnvelope) {
+	if response == nil || response.Response == nil {
+		fmt.Fprintln(w, "(no response)")
+		return
+	}
+	if response.Response.OutputSpeech != nil {
+		fmt.Fprintln(w, "Alexa:", response.Response.OutputSpeech.Text+response.Response.OutputSpeech.SSML)
+	}
+	if response.Response.Reprompt != nil && response.Response.Reprompt.OutputSpeech != nil {
+		fmt.Fprintln(w, "Reprompt:", response.Response.Reprompt.OutputSpeech.Text+response.Response.Reprompt.OutputSpeech.SSML)
+	}
+	attributes, _ := json.Marshal(response.SessionAttributes)
+	fmt.Fprintln(w, "Session attributes:", string(attributes))
+	fmt.Fprintln(w, "Session ends:", response.Response.ShouldSessionEnd)
+}
@@ -0,0 +1,91 @@
+// skill-server serves the skill over HTTP(S), e.g. behind a tunnel during development or as a self-hosted endpoint.
+//
+// It is configured through environment variables:
+//
+//	ADDR                         address to listen on, defaults to ":8080"
+//	TLS_CERT_FILE, TLS_KEY_FILE  serve HTTPS with this certificate and key
+//	ALEXA_APPLICATION_ID         only accept requests for this skill
+//	SKIP_SIGNATURE_VERIFICATION  set to "true" to accept requests not signed by Alexa
+//	SKIP_TIMESTAMP_VERIFICATION  set to "true" to accept requests with outdated timestamps
+//
+// Everything else is configured as for the Lambda function. With METRICS_FORMAT=prometheus, metrics are served at
+// /metrics.
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/petergtz/alexa-wikipedia/cmd/skill/factory"
+	"github.com/petergtz/alexa-wikipedia/skillserver"
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger := createLogger()
+	defer logger.Sync()
+
+	addr := os.Getenv("ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	skill := factory.CreateSkill(logger)
+	handler := &skillserver.Handler{
+		Skill:                     skill,
+		Logger:                    logger,
+		Redactor:                  skill.Redactor,
+		ExpectedApplicationID:     os.Getenv("ALEXA_APPLICATION_ID"),
+		SkipSignatureVerification: os.Getenv("SKIP_SIGNATURE_VERIFICATION") == "true",
+		SkipTimestampVerification: os.Getenv("SKIP_TIMESTAMP_VERIFICATION") == "true",
+	}
+	if handler.ExpectedApplicationID == "" {
+		logger.Warn("ALEXA_APPLICATION_ID not set. Accepting requests for any skill.")
+	}
+	if handler.SkipSignatureVerification || handler.SkipTimestampVerification {
+		logger.Warnw("Request verification partially disabled. Don't use this in production.",
+			"skip-signature-verification", handler.SkipSignatureVerification,
+			"skip-timestamp-verification", handler.SkipTimestampVerification)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	if metricsHandler, isHandler := skill.Metrics.(http.Handler); isHandler {
+		mux.Handle("/metrics", metricsHandler)
+	}
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		logger.Info("Shutting down")
+		server.Shutdown(context.Background())
+	}()
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	var e error
+	if certFile != "" || keyFile != "" {
+		logger.Infow("Serving HTTPS", "addr", addr)
+		e = server.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		logger.Infow("Serving HTTP", "addr", addr)
+		e = server.ListenAndServe()
+	}
+	if e != http.ErrServerClosed {
+		logger.Fatalw("Server stopped", "error", e)
+	}
+	skill.Close()
+}
+
+func createLogger() *zap.SugaredLogger {
+	loggerConfig := zap.NewProductionConfig()
+	loggerConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	loggerConfig.DisableStacktrace = true
+	logger, e := loggerConfig.Build()
+	if e != nil {
+		log.Panic(e)
+	}
+	return logger.Sugar()
+}
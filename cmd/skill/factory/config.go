@@ -0,0 +1,308 @@
+package factory
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+type Backend string
+
+// Profile is what a Config started from.
+type Profile string
+
+// WikipediaMode determines how the skill talks to Wikipedia.
+type WikipediaMode string
+
+// FindingsSink is where text quality findings go.
+type FindingsSink string
+
+// MetricsFormat is how metrics are emitted.
+type MetricsFormat string
+
+// TracingExporter is where tracing spans go.
+type TracingExporter string
+
+const (
+	ProfileDefault Profile = "default"
+	// ProfileOffline is for local use and tests. It's the only one that can do without a redaction secret.
+	ProfileOffline Profile = "offline"
+
+	BackendDynamoDB Backend = "dynamodb"
+	BackendInMemory Backend = "in-memory"
+	BackendNoOp     Backend = "no-op"
+
+	WikipediaModeLive WikipediaMode = "live"
+	// WikipediaModeRecord records all Wikipedia traffic to WikipediaCassette.
+	WikipediaModeRecord WikipediaMode = "record"
+	// WikipediaModeReplay answers requests to Wikipedia from WikipediaCassette without touching the network.
+	WikipediaModeReplay WikipediaMode = "replay"
+
+	FindingsSinkLog FindingsSink = "log"
+	// FindingsSinkFile appends findings to FindingsFile as JSON lines.
+	FindingsSinkFile     FindingsSink = "file"
+	FindingsSinkDynamoDB FindingsSink = "dynamodb"
+	// FindingsSinkGithub keeps findings in comments on FindingsGithubIssue in FindingsGithubRepo. It needs
+	// GithubToken.
+	FindingsSinkGithub FindingsSink = "github"
+
+	MetricsFormatNone MetricsFormat = "none"
+	// MetricsFormatEMF writes metrics to stdout in CloudWatch's Embedded Metric Format, so CloudWatch extracts them from
+	// the logs of the Lambda function.
+	MetricsFormatEMF MetricsFormat = "emf"
+	// MetricsFormatPrometheus keeps metrics in memory. skill-server serves them at /metrics.
+	MetricsFormatPrometheus MetricsFormat = "prometheus"
+
+	TracingExporterNone TracingExporter = "none"
+	// TracingExporterStdout writes each span to stdout as JSON line when it ends.
+	TracingExporterStdout TracingExporter = "stdout"
+)
+
+// Config determines which backends the skill uses. Use DefaultConfig or OfflineConfig as starting point.
+type Config struct {
+	// Profile can't be changed by config files.
+	Profile Profile `toml:"-"`
+	// Interactions is where interactions are logged and looked up. All backends are supported.
+	Interactions          Backend `toml:"interactions"`
+	InteractionsTableName string  `toml:"interactions_table_name"`
+	// Preferences is where user preferences are stored. BackendNoOp is not supported.
+	Preferences          Backend `toml:"preferences"`
+	PreferencesTableName string  `toml:"preferences_table_name"`
+	DynamoDBRegion       string  `toml:"dynamodb_region"`
+	// PrimeWikipedia makes a request to Wikipedia on start-up, so subsequent requests are faster.
+	PrimeWikipedia    bool          `toml:"prime_wikipedia"`
+	WikipediaMode     WikipediaMode `toml:"wikipedia_mode"`
+	WikipediaCassette string        `toml:"wikipedia_cassette"`
+	// WikipediaTransport is used to talk to Wikipedia in live and record mode. Defaults to http.DefaultTransport.
+	WikipediaTransport http.RoundTripper `toml:"-"`
+	Findings           FindingsSink      `toml:"findings"`
+	FindingsFile       string            `toml:"findings_file"`
+	FindingsTableName  string            `toml:"findings_table_name"`
+	// FindingsGithubRepo is given as "owner/repo".
+	FindingsGithubRepo  string `toml:"findings_github_repo"`
+	FindingsGithubIssue int    `toml:"findings_github_issue"`
+	// ErrorReportsGithubRepo is given as "owner/repo". When set, panics while handling requests are reported as issues
+	// there. It needs GithubToken.
+	ErrorReportsGithubRepo string `toml:"error_reports_github_repo"`
+	// ErrorReportsSNSTopicArn optionally also publishes error reports to an SNS topic.
+	ErrorReportsSNSTopicArn string `toml:"error_reports_sns_topic_arn"`
+	// ErrorReportsLogsURL links error reports to the logs. It must contain a %v for the error ID.
+	ErrorReportsLogsURL string        `toml:"error_reports_logs_url"`
+	MetricsFormat       MetricsFormat `toml:"metrics_format"`
+	// MetricsNamespace is the CloudWatch namespace of EMF metrics.
+	MetricsNamespace string          `toml:"metrics_namespace"`
+	TracingExporter  TracingExporter `toml:"tracing_exporter"`
+	// GithubToken can only be set through the environment, to keep it out of config files.
+	GithubToken string `toml:"-"`
+	// RedactionSecret keys the hashes of user and session IDs in logs, interaction logs and error reports. Like
+	// GithubToken, it can only be set through the environment. It's required except in ProfileOffline, which uses a
+	// random secret without it.
+	RedactionSecret string `toml:"-"`
+}
+
+// DefaultConfig is the configuration used in production. scripts/create-preferences-table.sh creates its preferences
+// table.
+func DefaultConfig() Config {
+	return Config{
+		Profile:               ProfileDefault,
+		Interactions:          BackendDynamoDB,
+		InteractionsTableName: "AlexaWikipediaRequests",
+		Preferences:           BackendDynamoDB,
+		PreferencesTableName:  "AlexaWikipediaUserPreferences",
+		DynamoDBRegion:        "eu-central-1",
+		PrimeWikipedia:        true,
+		WikipediaMode:         WikipediaModeLive,
+		Findings:              FindingsSinkLog,
+		MetricsFormat:         MetricsFormatEMF,
+		MetricsNamespace:      "AlexaWikipedia",
+		TracingExporter:       TracingExporterNone,
+	}
+}
+
+// OfflineConfig needs no AWS. Interactions and preferences are kept in memory. To not need a network connection
+// either, set WikipediaMode to WikipediaModeReplay.
+func OfflineConfig() Config {
+	return Config{
+		Profile:         ProfileOffline,
+		Interactions:    BackendInMemory,
+		Preferences:     BackendInMemory,
+		WikipediaMode:   WikipediaModeLive,
+		Findings:        FindingsSinkLog,
+		MetricsFormat:   MetricsFormatNone,
+		TracingExporter: TracingExporterNone,
+	}
+}
+
+// ConfigFromEnv is LoadConfig using the process's environment.
+func ConfigFromEnv() (Config, error) { return LoadConfig(os.Getenv) }
+
+// LoadConfig starts with the profile named by SKILL_PROFILE ("default" or "offline"), applies the TOML file named by
+// SKILL_CONFIG_FILE if set, and finally overrides individual settings from environment variables.
+func LoadConfig(getenv func(string) string) (Config, error) {
+	var config Config
+	switch getenv("SKILL_PROFILE") {
+	case "", "default":
+		config = DefaultConfig()
+	case "offline":
+		config = OfflineConfig()
+	default:
+		return Config{}, fmt.Errorf("unknown SKILL_PROFILE %q", getenv("SKILL_PROFILE"))
+	}
+
+	if path := getenv("SKILL_CONFIG_FILE"); path != "" {
+		if _, e := toml.DecodeFile(path, &config); e != nil {
+			return Config{}, fmt.Errorf("could not read SKILL_CONFIG_FILE: %w", e)
+		}
+	}
+
+	for name, value := range map[string]*string{
+		"TABLE_NAME_OVERRIDE":             &config.InteractionsTableName,
+		"PREFERENCES_TABLE_NAME_OVERRIDE": &config.PreferencesTableName,
+		"DYNAMODB_REGION":                 &config.DynamoDBRegion,
+		"INTERACTIONS_BACKEND":            (*string)(&config.Interactions),
+		"PREFERENCES_BACKEND":             (*string)(&config.Preferences),
+		"WIKIPEDIA_MODE":                  (*string)(&config.WikipediaMode),
+		"WIKIPEDIA_CASSETTE":              &config.WikipediaCassette,
+		"FINDINGS_SINK":                   (*string)(&config.Findings),
+		"FINDINGS_FILE":                   &config.FindingsFile,
+		"FINDINGS_TABLE_NAME":             &config.FindingsTableName,
+		"FINDINGS_GITHUB_REPO":            &config.FindingsGithubRepo,
+		"ERROR_REPORTS_GITHUB_REPO":       &config.ErrorReportsGithubRepo,
+		"ERROR_REPORTS_SNS_TOPIC_ARN":     &config.ErrorReportsSNSTopicArn,
+		"ERROR_REPORTS_LOGS_URL":          &config.ErrorReportsLogsURL,
+		"GITHUB_TOKEN":                    &config.GithubToken,
+		"REDACTION_SECRET":                &config.RedactionSecret,
+		"METRICS_FORMAT":                  (*string)(&config.MetricsFormat),
+		"METRICS_NAMESPACE":               &config.MetricsNamespace,
+		"TRACING_EXPORTER":                (*string)(&config.TracingExporter),
+	} {
+		if getenv(name) != "" {
+			*value = getenv(name)
+		}
+	}
+	if getenv("PRIME_WIKIPEDIA") != "" {
+		prime, e := strconv.ParseBool(getenv("PRIME_WIKIPEDIA"))
+		if e != nil {
+			return Config{}, fmt.Errorf("invalid PRIME_WIKIPEDIA: %w", e)
+		}
+		config.PrimeWikipedia = prime
+	}
+	if getenv("FINDINGS_GITHUB_ISSUE") != "" {
+		issue, e := strconv.Atoi(getenv("FINDINGS_GITHUB_ISSUE"))
+		if e != nil {
+			return Config{}, fmt.Errorf("invalid FINDINGS_GITHUB_ISSUE: %w", e)
+		}
+		config.FindingsGithubIssue = issue
+	}
+	return config, config.Validate()
+}
+
+func (c Config) Validate() error {
+	switch c.Interactions {
+	case BackendDynamoDB, BackendInMemory, BackendNoOp:
+	default:
+		return fmt.Errorf("unknown interactions backend %q", c.Interactions)
+	}
+	switch c.Preferences {
+	case BackendDynamoDB, BackendInMemory:
+	default:
+		return fmt.Errorf("unsupported preferences backend %q", c.Preferences)
+	}
+	if c.Interactions == BackendDynamoDB && c.InteractionsTableName == "" {
+		return fmt.Errorf("interactions table name must be set when using DynamoDB")
+	}
+	if c.Preferences == BackendDynamoDB && c.PreferencesTableName == "" {
+		return fmt.Errorf("preferences table name must be set when using DynamoDB")
+	}
+	switch c.WikipediaMode {
+	case WikipediaModeLive:
+	case WikipediaModeRecord, WikipediaModeReplay:
+		if c.WikipediaCassette == "" {
+			return fmt.Errorf("Wikipedia cassette must be set in Wikipedia mode %q", c.WikipediaMode)
+		}
+	default:
+		return fmt.Errorf("unknown Wikipedia mode %q", c.WikipediaMode)
+	}
+	switch c.Findings {
+	case FindingsSinkLog:
+	case FindingsSinkFile:
+		if c.FindingsFile == "" {
+			return fmt.Errorf("findings file must be set when using the file findings sink")
+		}
+	case FindingsSinkDynamoDB:
+		if c.FindingsTableName == "" {
+			return fmt.Errorf("findings table name must be set when using DynamoDB")
+		}
+	case FindingsSinkGithub:
+		if _, _, valid := c.findingsGithubOwnerAndRepo(); !valid || c.FindingsGithubIssue <= 0 {
+			return fmt.Errorf("findings GitHub repo must be given as \"owner/repo\" and the issue must be set when using the github findings sink")
+		}
+		if c.GithubToken == "" {
+			return fmt.Errorf("GitHub token must be set when using the github findings sink")
+		}
+	default:
+		return fmt.Errorf("unknown findings sink %q", c.Findings)
+	}
+	if c.ErrorReportsGithubRepo != "" {
+		if _, _, valid := c.errorReportsGithubOwnerAndRepo(); !valid {
+			return fmt.Errorf("error reports GitHub repo must be given as \"owner/repo\"")
+		}
+		if c.GithubToken == "" {
+			return fmt.Errorf("GitHub token must be set when reporting errors on GitHub")
+		}
+	}
+	if c.ErrorReportsSNSTopicArn != "" {
+		if c.ErrorReportsGithubRepo == "" {
+			return fmt.Errorf("error reports GitHub repo must be set when using an SNS topic for error reports")
+		}
+		if _, e := arn.Parse(c.ErrorReportsSNSTopicArn); e != nil {
+			return fmt.Errorf("invalid error reports SNS topic ARN: %w", e)
+		}
+	}
+	switch c.MetricsFormat {
+	case MetricsFormatNone, MetricsFormatPrometheus:
+	case MetricsFormatEMF:
+		if c.MetricsNamespace == "" {
+			return fmt.Errorf("metrics namespace must be set when using EMF metrics")
+		}
+	default:
+		return fmt.Errorf("unknown metrics format %q", c.MetricsFormat)
+	}
+	switch c.TracingExporter {
+	case TracingExporterNone, TracingExporterStdout:
+	default:
+		return fmt.Errorf("unknown tracing exporter %q", c.TracingExporter)
+	}
+	if c.usesDynamoDB() && c.DynamoDBRegion == "" {
+		return fmt.Errorf("DynamoDB region must be set when using DynamoDB")
+	}
+	if c.Profile != ProfileOffline && c.RedactionSecret == "" {
+		return fmt.Errorf("redaction secret must be set unless using the offline profile")
+	}
+	return nil
+}
+
+func (c Config) usesDynamoDB() bool {
+	return c.Interactions == BackendDynamoDB || c.Preferences == BackendDynamoDB || c.Findings == FindingsSinkDynamoDB
+}
+
+func (c Config) findingsGithubOwnerAndRepo() (owner string, repo string, valid bool) {
+	return ownerAndRepo(c.FindingsGithubRepo)
+}
+
+func (c Config) errorReportsGithubOwnerAndRepo() (owner string, repo string, valid bool) {
+	return ownerAndRepo(c.ErrorReportsGithubRepo)
+}
+
+func ownerAndRepo(ownerAndRepo string) (owner string, repo string, valid bool) {
+	parts := strings.Split(ownerAndRepo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,173 @@
+package factory_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/cmd/skill/factory"
+)
+
+var _ = Describe("LoadConfig", func() {
+	var env map[string]string
+
+	BeforeEach(func() { env = map[string]string{"REDACTION_SECRET": "secret"} })
+
+	load := func() (factory.Config, error) {
+		return factory.LoadConfig(func(name string) string { return env[name] })
+	}
+
+	It("defaults to the production configuration", func() {
+		config := factory.DefaultConfig()
+		config.RedactionSecret = "secret"
+
+		Expect(load()).To(Equal(config))
+	})
+
+	It("uses the offline profile", func() {
+		env["SKILL_PROFILE"] = "offline"
+		delete(env, "REDACTION_SECRET")
+
+		Expect(load()).To(Equal(factory.OfflineConfig()))
+	})
+
+	It("requires a redaction secret unless using the offline profile", func() {
+		delete(env, "REDACTION_SECRET")
+
+		_, e := load()
+
+		Expect(e).To(MatchError(ContainSubstring("redaction secret must be set")))
+	})
+
+	It("applies the config file and then the environment", func() {
+		dir, e := os.MkdirTemp("", "factory")
+		Expect(e).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		Expect(os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`
+interactions = "no-op"
+preferences_table_name = "FromFile"
+dynamodb_region = "us-east-1"
+`), 0644)).To(Succeed())
+		env["SKILL_CONFIG_FILE"] = filepath.Join(dir, "config.toml")
+		env["DYNAMODB_REGION"] = "eu-west-1"
+		env["PRIME_WIKIPEDIA"] = "false"
+
+		config, e := load()
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(config).To(Equal(factory.Config{
+			Profile:               factory.ProfileDefault,
+			Interactions:          factory.BackendNoOp,
+			InteractionsTableName: "AlexaWikipediaRequests",
+			Preferences:           factory.BackendDynamoDB,
+			PreferencesTableName:  "FromFile",
+			DynamoDBRegion:        "eu-west-1",
+			WikipediaMode:         factory.WikipediaModeLive,
+			Findings:              factory.FindingsSinkLog,
+			MetricsFormat:         factory.MetricsFormatEMF,
+			MetricsNamespace:      "AlexaWikipedia",
+			TracingExporter:       factory.TracingExporterNone,
+			RedactionSecret:       "secret",
+		}))
+	})
+
+	It("configures the GitHub findings sink from the environment", func() {
+		env["FINDINGS_SINK"] = "github"
+		env["FINDINGS_GITHUB_REPO"] = "petergtz/alexa-wikipedia"
+		env["FINDINGS_GITHUB_ISSUE"] = "42"
+		env["GITHUB_TOKEN"] = "secret"
+
+		config, e := load()
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(config.Findings).To(Equal(factory.FindingsSinkGithub))
+		Expect(config.FindingsGithubRepo).To(Equal("petergtz/alexa-wikipedia"))
+		Expect(config.FindingsGithubIssue).To(Equal(42))
+		Expect(config.GithubToken).To(Equal("secret"))
+	})
+
+	It("configures error reports and redaction from the environment", func() {
+		env["ERROR_REPORTS_GITHUB_REPO"] = "petergtz/alexa-wikipedia"
+		env["ERROR_REPORTS_SNS_TOPIC_ARN"] = "arn:aws:sns:eu-central-1:123456789012:alexa-wikipedia-errors"
+		env["ERROR_REPORTS_LOGS_URL"] = "https://logs.example.com/?error-id=%v"
+		env["GITHUB_TOKEN"] = "secret"
+		env["REDACTION_SECRET"] = "another secret"
+
+		config, e := load()
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(config.RedactionSecret).To(Equal("another secret"))
+		Expect(config.ErrorReportsGithubRepo).To(Equal("petergtz/alexa-wikipedia"))
+		Expect(config.ErrorReportsSNSTopicArn).To(Equal("arn:aws:sns:eu-central-1:123456789012:alexa-wikipedia-errors"))
+		Expect(config.ErrorReportsLogsURL).To(Equal("https://logs.example.com/?error-id=%v"))
+	})
+
+	It("rejects invalid configurations", func() {
+		env["SKILL_PROFILE"] = "staging"
+		_, e := load()
+		Expect(e).To(MatchError(ContainSubstring("unknown SKILL_PROFILE")))
+
+		env["SKILL_PROFILE"] = "offline"
+		env["PREFERENCES_BACKEND"] = "no-op"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("unsupported preferences backend")))
+
+		delete(env, "PREFERENCES_BACKEND")
+		env["INTERACTIONS_BACKEND"] = "dynamodb"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("table name must be set")))
+
+		delete(env, "INTERACTIONS_BACKEND")
+		env["WIKIPEDIA_MODE"] = "replay"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("cassette must be set")))
+
+		delete(env, "WIKIPEDIA_MODE")
+		env["FINDINGS_SINK"] = "file"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("findings file must be set")))
+
+		env["FINDINGS_SINK"] = "github"
+		env["FINDINGS_GITHUB_REPO"] = "alexa-wikipedia"
+		env["FINDINGS_GITHUB_ISSUE"] = "42"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring(`given as "owner/repo"`)))
+
+		env["FINDINGS_GITHUB_REPO"] = "petergtz/alexa-wikipedia"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("GitHub token must be set")))
+
+		env["FINDINGS_SINK"] = "log"
+		env["ERROR_REPORTS_GITHUB_REPO"] = "alexa-wikipedia"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring(`error reports GitHub repo must be given as "owner/repo"`)))
+
+		env["ERROR_REPORTS_GITHUB_REPO"] = "petergtz/alexa-wikipedia"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("GitHub token must be set when reporting errors")))
+
+		env["GITHUB_TOKEN"] = "secret"
+		env["ERROR_REPORTS_SNS_TOPIC_ARN"] = "alexa-wikipedia-errors"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("invalid error reports SNS topic ARN")))
+
+		delete(env, "ERROR_REPORTS_GITHUB_REPO")
+		delete(env, "ERROR_REPORTS_SNS_TOPIC_ARN")
+		delete(env, "GITHUB_TOKEN")
+		env["METRICS_FORMAT"] = "statsd"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("unknown metrics format")))
+
+		delete(env, "METRICS_FORMAT")
+		env["TRACING_EXPORTER"] = "jaeger"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("unknown tracing exporter")))
+
+		delete(env, "TRACING_EXPORTER")
+		env["FINDINGS_SINK"] = "email"
+		_, e = load()
+		Expect(e).To(MatchError(ContainSubstring("unknown findings sink")))
+	})
+})
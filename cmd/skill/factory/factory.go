@@ -1,69 +1,216 @@
 package factory
 
 import (
+	"crypto/rand"
 	"net/http"
 	"os"
 
 	"github.com/BurntSushi/toml"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/alexa-wikipedia/async"
+	"github.com/petergtz/alexa-wikipedia/findings"
+	"github.com/petergtz/alexa-wikipedia/github"
+	"github.com/petergtz/alexa-wikipedia/interactions"
 	"github.com/petergtz/alexa-wikipedia/locale"
 	"github.com/petergtz/alexa-wikipedia/mediawiki"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/recording"
+	"github.com/petergtz/alexa-wikipedia/redaction"
 	"github.com/petergtz/alexa-wikipedia/skill"
+	"github.com/petergtz/alexa-wikipedia/tracing"
 	"golang.org/x/text/language"
 
 	"go.uber.org/zap"
 
 	awsdyndb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/petergtz/go-alexa"
 	"github.com/petergtz/go-alexa/decorator"
 	"github.com/petergtz/go-alexa/dynamodb"
 )
 
-type noOpWikiPagePreprocessor struct{}
+// Skill is the skill together with its background work.
+type Skill struct {
+	*decorator.InteractionLoggingSkill
+	// Metrics is an http.Handler serving the metrics when they are kept in memory.
+	Metrics metrics.Metrics
+	// Redactor is what the skill redacts logged IDs and texts with.
+	Redactor *redaction.Redactor
+	worker   *async.Worker
+}
 
-func (*noOpWikiPagePreprocessor) Process(p *mediawiki.Page) *mediawiki.Page { return p }
+// Close waits until background work, like persisting text quality findings, is done. The skill must not be used
+// afterwards.
+func (s *Skill) Close() {
+	s.worker.Close()
+}
 
-func CreateSkill(logger *zap.SugaredLogger) *decorator.InteractionLoggingSkill {
-	// this is a pure priming call to make subsequent calls faster
-	http.Get("https://en.wikipedia.org/w/api.php?format=json&action=query&prop=extracts&titles=Keepalive&redirects=true&formatversion=2&explaintext=true&exlimit=1")
+// Flush waits until background work started so far is done. On Lambda it must be called before responding, because
+// the function instance is frozen afterwards.
+func (s *Skill) Flush() {
+	s.worker.Flush()
+}
+
+// CreateSkill creates the skill as configured by ConfigFromEnv.
+func CreateSkill(logger *zap.SugaredLogger) *Skill {
+	config, e := ConfigFromEnv()
+	if e != nil {
+		logger.Fatalw("Invalid configuration", "error", e)
+	}
+	return CreateSkillFrom(config, logger)
+}
+
+func CreateSkillFrom(config Config, logger *zap.SugaredLogger) *Skill {
+	loggedConfig := config
+	if loggedConfig.GithubToken != "" {
+		loggedConfig.GithubToken = "REDACTED"
+	}
+	if loggedConfig.RedactionSecret != "" {
+		loggedConfig.RedactionSecret = "REDACTED"
+	}
+	logger.Infow("Creating skill", "config", loggedConfig)
 
-	tableName := "AlexaWikipediaRequests"
-	if os.Getenv("TABLE_NAME_OVERRIDE") != "" {
-		tableName = os.Getenv("TABLE_NAME_OVERRIDE")
-		logger.Infow("Using DynamoDB table override", "table", tableName)
+	httpClient := &http.Client{Transport: config.WikipediaTransport}
+	switch config.WikipediaMode {
+	case WikipediaModeRecord:
+		httpClient = &http.Client{Transport: &recording.Recorder{Path: config.WikipediaCassette, Next: config.WikipediaTransport}}
+	case WikipediaModeReplay:
+		httpClient = &http.Client{Transport: &recording.Replayer{Path: config.WikipediaCassette}}
 	}
 
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" {
-		logger.Fatal("GITHUB_TOKEN not set. Please set it to a valid token from Github.")
+	if config.PrimeWikipedia && config.WikipediaMode == WikipediaModeLive {
+		// this is a pure priming call to make subsequent calls faster
+		go http.Get("https://en.wikipedia.org/w/api.php?format=json&action=query&prop=extracts&titles=Keepalive&redirects=true&formatversion=2&explaintext=true&exlimit=1")
 	}
 
-	interactionLogger := dynamodb.NewInteractionLogger(
-		awsdyndb.New(session.Must(session.NewSession(&aws.Config{Region: aws.String("eu-central-1")}))),
-		logger,
-		tableName)
+	var dynamoClient *awsdyndb.DynamoDB
+	if config.usesDynamoDB() {
+		dynamoClient = awsdyndb.New(session.Must(session.NewSession(&aws.Config{Region: aws.String(config.DynamoDBRegion)})))
+	}
+
+	redactor := createRedactor(config, logger)
+	metricsRecorder := createMetrics(config)
+
+	var interactionStore interactions.Store
+	switch config.Interactions {
+	case BackendDynamoDB:
+		interactionStore = dynamodb.NewInteractionLogger(dynamoClient, logger, config.InteractionsTableName)
+	case BackendInMemory:
+		interactionStore = interactions.NewInMemoryStore()
+	default:
+		interactionStore = interactions.NoOpStore{}
+	}
+	interactionStore = &interactions.RedactingStore{Store: interactionStore, Redactor: redactor}
+
+	var preferencesStore preferences.Store
+	switch config.Preferences {
+	case BackendDynamoDB:
+		preferencesStore = preferences.NewDynamoDBStore(dynamoClient, config.PreferencesTableName)
+	default:
+		preferencesStore = preferences.NewInMemoryStore()
+	}
+
+	worker := async.NewWorker(100, metricsRecorder, logger)
+	findingsPersistence := &mediawiki.AsyncPersistence{
+		Persistence: createFindingsPersistence(config, dynamoClient, logger),
+		Worker:      worker,
+		Logger:      logger,
+	}
 
-	return decorator.ForSkillWithInteractionLogging(
+	interactionLoggingSkill := decorator.ForSkillWithInteractionLogging(
 		skill.NewWikipediaSkill(
 			&mediawiki.MediaWiki{
 				Logger:               logger,
-				WikiPagePreProcessor: &noOpWikiPagePreprocessor{},
+				WikiPagePreProcessor: mediawiki.NewTextQualityPipeline(findingsPersistence, logger),
+				HTTPClient:           httpClient,
+				Metrics:              metricsRecorder,
 			},
-			createI18nBundle(),
-			interactionLogger,
-			interactionLogger,
+			CreateI18nBundle(),
+			interactionStore,
+			interactionStore,
+			preferencesStore,
+			createErrorReporter(config, redactor, worker, logger),
+			metricsRecorder,
+			createTracer(config),
 			logger,
 		),
-		interactionLogger,
+		interactionStore,
 		func(requestEnv *alexa.RequestEnvelope) bool {
 			return !(requestEnv.Request.Type == "IntentRequest" && requestEnv.Request.Intent.Name == "DefineIntent")
 		},
 	)
+	return &Skill{InteractionLoggingSkill: interactionLoggingSkill, Metrics: metricsRecorder, Redactor: redactor, worker: worker}
+}
+
+func createFindingsPersistence(config Config, dynamoClient *awsdyndb.DynamoDB, logger *zap.SugaredLogger) mediawiki.Persistence {
+	switch config.Findings {
+	case FindingsSinkFile:
+		return findings.NewFilePersistence(config.FindingsFile)
+	case FindingsSinkDynamoDB:
+		return findings.NewDynamoDBPersistence(dynamoClient, config.FindingsTableName)
+	case FindingsSinkGithub:
+		owner, repo, _ := config.findingsGithubOwnerAndRepo()
+		return github.NewShardedPersistence(owner, repo, config.FindingsGithubIssue, config.GithubToken)
+	default:
+		return &mediawiki.LoggingPersistence{Logger: logger}
+	}
+}
+
+// createErrorReporter returns nil when error reports are not configured, so panics are only logged.
+func createErrorReporter(config Config, redactor *redaction.Redactor, worker *async.Worker, logger *zap.SugaredLogger) skill.ErrorReporter {
+	if config.ErrorReportsGithubRepo == "" {
+		return nil
+	}
+	var snsClient *sns.SNS
+	if config.ErrorReportsSNSTopicArn != "" {
+		topicArn, _ := arn.Parse(config.ErrorReportsSNSTopicArn)
+		snsClient = sns.New(session.Must(session.NewSession(&aws.Config{Region: aws.String(topicArn.Region)})))
+	}
+	owner, repo, _ := config.errorReportsGithubOwnerAndRepo()
+	errorReporter := github.NewErrorReporter(owner, repo, config.GithubToken, logger, config.ErrorReportsLogsURL, snsClient, config.ErrorReportsSNSTopicArn)
+	errorReporter.Redactor = redactor
+	errorReporter.Worker = worker
+	return errorReporter
+}
+
+// createTracer returns nil when tracing is off, so no spans are started.
+func createTracer(config Config) *tracing.Tracer {
+	switch config.TracingExporter {
+	case TracingExporterStdout:
+		return tracing.NewTracer(tracing.NewJSONExporter(os.Stdout))
+	default:
+		return nil
+	}
+}
+
+func createMetrics(config Config) metrics.Metrics {
+	switch config.MetricsFormat {
+	case MetricsFormatEMF:
+		return metrics.NewEMF(os.Stdout, config.MetricsNamespace)
+	case MetricsFormatPrometheus:
+		return metrics.NewPrometheus(metrics.DefaultBuckets)
+	default:
+		return metrics.NoOp{}
+	}
+}
+
+func createRedactor(config Config, logger *zap.SugaredLogger) *redaction.Redactor {
+	if config.RedactionSecret != "" {
+		return redaction.NewRedactor([]byte(config.RedactionSecret))
+	}
+	// Only the offline profile gets here
+	secret := make([]byte, 32)
+	if _, e := rand.Read(secret); e != nil {
+		logger.Fatalw("Could not create redaction secret", "error", e)
+	}
+	return redaction.NewRedactor(secret)
 }
 
-func createI18nBundle() *i18n.Bundle {
+func CreateI18nBundle() *i18n.Bundle {
 	i18nBundle := i18n.NewBundle(language.English)
 	i18nBundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
 
@@ -1,19 +1,74 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"log"
 	"math/rand"
 	"time"
 
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/petergtz/alexa-wikipedia/apl"
 	"github.com/petergtz/alexa-wikipedia/cmd/skill/factory"
-	"github.com/petergtz/go-alexa/lambda"
+	"github.com/petergtz/alexa-wikipedia/redaction"
+	"github.com/petergtz/go-alexa"
 	"go.uber.org/zap"
 )
 
 func main() {
 	logger := createLoggerWith(zap.NewAtomicLevelAt(zap.DebugLevel))
 	defer logger.Sync()
-	lambda.StartLambdaSkill(factory.CreateSkill(logger), logger)
+	skill := factory.CreateSkill(logger)
+	startLambdaSkill(skill, skill.Redactor, logger, skill.Flush)
+}
+
+// startLambdaSkill replaces alexa.StartLambdaSkill, because only apl.DecodeRequestEnvelope keeps the arguments of APL
+// UserEvents. Lambda freezes the function instance after each invocation and sends no SIGTERM without an extension, so
+// background work is flushed before responding.
+func startLambdaSkill(skill alexa.Skill, redactor *redaction.Redactor, logger *zap.SugaredLogger, flush func()) {
+	invocationCount := 0
+	lambda.Start(func(ctx context.Context, event json.RawMessage) (alexa.ResponseEnvelope, error) {
+		invocationCount++
+		lc, _ := lambdacontext.FromContext(ctx)
+
+		var requestEnv alexa.RequestEnvelope
+		e := apl.DecodeRequestEnvelope(event, &requestEnv)
+		if e != nil {
+			return alexa.ResponseEnvelope{}, e
+		}
+
+		if requestEnv.Request == nil {
+			logger.Infow("Keep-alive CloudWatch Request",
+				"aws-request-id", lc.AwsRequestID,
+				"function-invocation-count", invocationCount)
+
+			return alexa.ResponseEnvelope{}, nil
+		}
+		logger.Infow("Alexa Request",
+			"aws-request-id", lc.AwsRequestID,
+			"alexa-request-id", requestEnv.Request.RequestID,
+			"user-id", redactor.Hash(requestEnv.Session.User.UserID),
+			"session-id", redactor.Hash(requestEnv.Session.SessionID),
+			"locale", requestEnv.Request.Locale,
+			"type", requestEnv.Request.Type,
+			"intent", requestEnv.Request.Intent.Name,
+			"session-attributes", redactor.Value(requestEnv.Session.Attributes),
+			"function-invocation-count", invocationCount,
+		)
+
+		result := *skill.ProcessRequest(&requestEnv)
+		flush()
+
+		logger.Infow("Alexa Response",
+			"aws-request-id", lc.AwsRequestID,
+			"alexa-request-id", requestEnv.Request.RequestID,
+			"response", redactor.Value(result.Response),
+			"session-attributes", redactor.Value(result.SessionAttributes),
+		)
+
+		return result, nil
+	})
 }
 
 func createLoggerWith(logLevel zap.AtomicLevel) *zap.SugaredLogger {
@@ -0,0 +1,54 @@
+package findings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/pkg/errors"
+)
+
+// DynamoDBPersistence stores each finding as an item of its own in a table with the string attribute "Fingerprint" as
+// partition key. Items therefore stay small no matter how many findings there are. Putting the same finding again
+// overwrites its item, so concurrent function instances don't lose each other's findings, and findings are stored only
+// once.
+type DynamoDBPersistence struct {
+	dynamo    *dynamodb.DynamoDB
+	tableName string
+}
+
+func NewDynamoDBPersistence(dynamoClient *dynamodb.DynamoDB, tableName string) *DynamoDBPersistence {
+	return &DynamoDBPersistence{
+		dynamo:    dynamoClient,
+		tableName: tableName,
+	}
+}
+
+type item struct {
+	Fingerprint string `dynamodbav:"Fingerprint"`
+	Finding     string `dynamodbav:"Finding"`
+}
+
+func (p *DynamoDBPersistence) Persist(findings []string) error {
+	for _, finding := range findings {
+		input, e := dynamodbattribute.MarshalMap(item{Fingerprint: fingerprint(finding), Finding: finding})
+		if e != nil {
+			return errors.Wrap(e, "Could not marshal finding")
+		}
+		_, e = p.dynamo.PutItem(&dynamodb.PutItemInput{
+			Item:      input,
+			TableName: &p.tableName,
+		})
+		if e != nil {
+			return errors.Wrap(e, "Could not put finding")
+		}
+	}
+	return nil
+}
+
+// fingerprint identifies a finding. Unlike the finding itself, it's short enough for a DynamoDB key.
+func fingerprint(finding string) string {
+	hash := sha256.Sum256([]byte(finding))
+	return hex.EncodeToString(hash[:])
+}
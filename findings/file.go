@@ -0,0 +1,47 @@
+// Package findings contains persistences for text quality findings, see mediawiki.Persistence.
+package findings
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FilePersistence appends findings to a file, one JSON object per line, e.g. for local development.
+type FilePersistence struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFilePersistence(path string) *FilePersistence {
+	return &FilePersistence{path: path}
+}
+
+// Record is a line in the file.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Finding string    `json:"finding"`
+}
+
+func (p *FilePersistence) Persist(findings []string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	file, e := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if e != nil {
+		return errors.Wrap(e, "Could not open findings file")
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	now := time.Now().UTC()
+	for _, finding := range findings {
+		e = encoder.Encode(Record{Time: now, Finding: finding})
+		if e != nil {
+			return errors.Wrap(e, "Could not write to findings file")
+		}
+	}
+	return errors.Wrap(file.Close(), "Could not close findings file")
+}
@@ -0,0 +1,56 @@
+package findings_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/findings"
+)
+
+var _ = Describe("FilePersistence", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var e error
+		dir, e = os.MkdirTemp("", "findings")
+		Expect(e).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	readRecords := func(path string) []string {
+		file, e := os.Open(path)
+		Expect(e).NotTo(HaveOccurred())
+		defer file.Close()
+		var result []string
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record findings.Record
+			Expect(json.Unmarshal(scanner.Bytes(), &record)).To(Succeed())
+			Expect(record.Time).NotTo(BeZero())
+			result = append(result, record.Finding)
+		}
+		return result
+	}
+
+	It("appends one line per finding", func() {
+		path := filepath.Join(dir, "findings.jsonl")
+		persistence := findings.NewFilePersistence(path)
+
+		Expect(persistence.Persist([]string{"Satz.Das", "multi\nline"})).To(Succeed())
+		Expect(persistence.Persist([]string{"e.V.Berlin"})).To(Succeed())
+
+		Expect(readRecords(path)).To(Equal([]string{"Satz.Das", "multi\nline", "e.V.Berlin"}))
+	})
+
+	It("fails when the file cannot be written", func() {
+		Expect(findings.NewFilePersistence(filepath.Join(dir, "missing", "findings.jsonl")).Persist([]string{"x"})).
+			To(MatchError(ContainSubstring("Could not open findings file")))
+	})
+})
@@ -0,0 +1,13 @@
+package findings_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFindings(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Findings Suite")
+}
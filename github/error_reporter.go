@@ -1,13 +1,18 @@
 package github
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha1"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"runtime"
 	"runtime/debug"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sns"
@@ -16,10 +21,32 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/google/go-github/github"
+	"github.com/petergtz/alexa-wikipedia/async"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 )
 
+const (
+	// DefaultDedupWindow is how long further occurrences of an error are only logged after it was reported.
+	DefaultDedupWindow = time.Hour
+	// DefaultRateLimit is how many reports per DefaultRateLimitPeriod go to GitHub and SNS, across all errors. Like
+	// deduplication, it only applies per process, i.e. per function instance.
+	DefaultRateLimit       = 10
+	DefaultRateLimitPeriod = time.Hour
+	// DefaultFailureBackoff is how long further occurrences of an error are only logged after reporting it on GitHub
+	// failed.
+	DefaultFailureBackoff = 5 * time.Minute
+
+	maxFingerprintFrames = 20
+)
+
+// ErrorReporter reports errors as GitHub issues and, if it has an SNS client, via SNS. Errors with the same
+// fingerprint, i.e. the same type and stack, share an issue: while one is open, further occurrences are commented on
+// it. Within DedupWindow after a report, or FailureBackoff after a failed one, further occurrences are only logged.
+// RateLimit limits the reports across all errors. Deduplication and rate limit only apply within a process, so
+// concurrent function instances each report on their own. Once it knows an error's issue, it only checks whether the
+// issue is still open instead of searching for it again, to stay clear of the Search API's low rate limit and its
+// delay. Calls to GitHub and SNS happen outside of the lock, so concurrent reports don't wait for each other.
 type ErrorReporter struct {
 	ghClient    *github.Client
 	logger      *zap.SugaredLogger
@@ -29,46 +56,122 @@ type ErrorReporter struct {
 	logsURL     string
 	snsClient   *sns.SNS
 	snsTopicArn string
+
+	DedupWindow     time.Duration
+	FailureBackoff  time.Duration
+	RateLimit       int
+	RateLimitPeriod time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+	// Redactor, if set, removes personal data from the error and its context before they are logged or published.
+	Redactor Redactor
+	// Worker, if set, reports on GitHub and SNS in the background, so reporting doesn't delay the response. The
+	// fingerprint is still taken right away, as it depends on the stack of the caller.
+	Worker *async.Worker
+
+	mutex             sync.Mutex
+	reports           map[string]*report
+	rateLimitStart    time.Time
+	reportsInPeriod   int
+	rateLimitedErrors int
+}
+
+// Redactor removes personal data from text and from values that are marshalled to JSON.
+type Redactor interface {
+	Text(text string) string
+	Value(v interface{}) interface{}
+}
+
+type report struct {
+	issueNumber int
+	// until is when the next occurrence gets reported again.
+	until      time.Time
+	suppressed int
 }
 
 func NewErrorReporter(owner, repo, token string, logger *zap.SugaredLogger, logsURL string, snsClient *sns.SNS, snsTopicArn string) *ErrorReporter {
 	ctx := context.TODO()
+	return NewErrorReporterWithClient(
+		github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))),
+		owner, repo, logger, logsURL, snsClient, snsTopicArn)
+}
+
+func NewErrorReporterWithClient(ghClient *github.Client, owner, repo string, logger *zap.SugaredLogger, logsURL string, snsClient *sns.SNS, snsTopicArn string) *ErrorReporter {
 	spew.Config.ContinueOnMethod = true
 	return &ErrorReporter{
-		ghClient:    github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))),
-		ctx:         ctx,
-		logger:      logger,
-		repo:        repo,
-		owner:       owner,
-		logsURL:     logsURL,
-		snsClient:   snsClient,
-		snsTopicArn: snsTopicArn,
+		ghClient:        ghClient,
+		ctx:             context.TODO(),
+		logger:          logger,
+		repo:            repo,
+		owner:           owner,
+		logsURL:         logsURL,
+		snsClient:       snsClient,
+		snsTopicArn:     snsTopicArn,
+		DedupWindow:     DefaultDedupWindow,
+		FailureBackoff:  DefaultFailureBackoff,
+		RateLimit:       DefaultRateLimit,
+		RateLimitPeriod: DefaultRateLimitPeriod,
+		reports:         make(map[string]*report),
 	}
 }
 
 func (r *ErrorReporter) ReportPanic(e interface{}, context interface{}) {
+	stack := make([]uintptr, 64)
+	stack = stack[:runtime.Callers(2, stack)]
+
 	errorID := rand.Int63()
+	fingerprint := Fingerprint(e, stack)
 	errorString := errorStringFrom(e)
+	if r.Redactor != nil {
+		errorString = r.Redactor.Text(errorString)
+		context = r.Redactor.Value(context)
+	}
+	attributes := map[string]interface{}{
+		"error-id":    errorID,
+		"error":       errorString,
+		"fingerprint": fingerprint,
+	}
 
-	issue, _, ghErr := r.ghClient.Issues.Create(r.ctx, r.owner, r.repo, &github.IssueRequest{
-		Title: github.String(fmt.Sprintf("Internal Server Error (ErrID: %v)", errorID)),
-		Body:  github.String(fmt.Sprintf("An error occurred and it can be found using %v", fmt.Sprintf(r.logsURL, errorID))),
-	})
+	suppressed, knownIssueNumber, inProgress := r.startReport(fingerprint, attributes)
+	if inProgress == nil {
+		return
+	}
+	finish := func() {
+		r.finishReport(fingerprint, knownIssueNumber, errorID, suppressed, attributes, context, inProgress)
+	}
+	if r.Worker == nil {
+		finish()
+		return
+	}
+	if !r.Worker.Enqueue(finish) {
+		r.logger.Errorw("Internal Server Error (not reported, as the background queue is full)", slicify(attributes)...)
+	}
+}
 
-	attributes := map[string]interface{}{
-		"error-id": errorID,
-		"error":    errorString,
+// finishReport reports on GitHub and SNS and records the outcome in inProgress.
+func (r *ErrorReporter) finishReport(fingerprint string, knownIssueNumber int, errorID int64, suppressed int, attributes map[string]interface{}, context interface{}, inProgress *report) {
+	issueNumber, issueURL, ghErr := r.reportOnGithub(fingerprint, knownIssueNumber, errorID, suppressed)
+	r.mutex.Lock()
+	if ghErr != nil {
+		inProgress.until = r.now().Add(r.FailureBackoff)
+		inProgress.suppressed += suppressed
+	} else {
+		inProgress.issueNumber = issueNumber
 	}
+	r.mutex.Unlock()
 	if ghErr != nil {
 		attributes["github-error"] = ghErr
 
 		r.logger.Errorw("Error while trying to report Internal Server Error", slicify(attributes)...)
 	} else {
-		attributes["issue-url"] = issue.GetHTMLURL()
+		attributes["issue-url"] = issueURL
 
 		r.logger.Errorw("Internal Server Error", slicify(attributes)...)
 	}
 
+	if r.snsClient == nil {
+		return
+	}
 	_, snsErr := r.snsClient.Publish(&sns.PublishInput{
 		TopicArn: aws.String(r.snsTopicArn),
 		Subject:  aws.String(fmt.Sprintf(r.repo+": Internal Server Error (ErrID: %v)", errorID)),
@@ -89,10 +192,150 @@ CLOUDWATCH QUERY:
 	}
 }
 
+// startReport decides whether the error with fingerprint gets reported. If so, it records the report right away, so
+// concurrent occurrences are deduplicated while it is in progress, and returns it along with the number of occurrences
+// suppressed since the previous report and the previous report's issue. Otherwise, it logs the error and returns nil.
+func (r *ErrorReporter) startReport(fingerprint string, attributes map[string]interface{}) (suppressed int, issueNumber int, started *report) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	previous := r.reports[fingerprint]
+	if previous != nil && now.Before(previous.until) {
+		previous.suppressed++
+		attributes["issue-number"] = previous.issueNumber
+		r.logger.Errorw("Internal Server Error (already reported)", slicify(attributes)...)
+		return 0, 0, nil
+	}
+	if !r.allowedByRateLimit(now) {
+		r.rateLimitedErrors++
+		attributes["rate-limited-errors"] = r.rateLimitedErrors
+		r.logger.Errorw("Internal Server Error (not reported due to rate limit)", slicify(attributes)...)
+		return 0, 0, nil
+	}
+	started = &report{until: now.Add(r.DedupWindow)}
+	if previous != nil {
+		suppressed = previous.suppressed
+		started.issueNumber = previous.issueNumber
+	}
+	r.reports[fingerprint] = started
+	return suppressed, started.issueNumber, started
+}
+
+// reportOnGithub comments on the open issue for fingerprint if there is one, or creates one otherwise.
+func (r *ErrorReporter) reportOnGithub(fingerprint string, knownIssueNumber int, errorID int64, suppressed int) (issueNumber int, url string, e error) {
+	issueNumber, e = r.openIssue(fingerprint, knownIssueNumber)
+	if e != nil {
+		return 0, "", e
+	}
+	if issueNumber != 0 {
+		comment, _, e := r.ghClient.Issues.CreateComment(r.ctx, r.owner, r.repo, issueNumber, &github.IssueComment{
+			Body: github.String(fmt.Sprintf("Occurred again (ErrID: %v). It can be found using %v\n\nOccurrences not reported since the last report: %v",
+				errorID, fmt.Sprintf(r.logsURL, errorID), suppressed)),
+		})
+		if e != nil {
+			return 0, "", e
+		}
+		return issueNumber, comment.GetHTMLURL(), nil
+	}
+
+	issue, _, e := r.ghClient.Issues.Create(r.ctx, r.owner, r.repo, &github.IssueRequest{
+		Title: github.String(fmt.Sprintf("Internal Server Error (Fingerprint: %v)", fingerprint)),
+		Body:  github.String(fmt.Sprintf("An error occurred and it can be found using %v", fmt.Sprintf(r.logsURL, errorID))),
+	})
+	if e != nil {
+		return 0, "", e
+	}
+	return issue.GetNumber(), issue.GetHTMLURL(), nil
+}
+
+// openIssue returns knownIssueNumber if that issue is still open, and searches for an open issue otherwise.
+func (r *ErrorReporter) openIssue(fingerprint string, knownIssueNumber int) (int, error) {
+	if knownIssueNumber != 0 {
+		issue, _, e := r.ghClient.Issues.Get(r.ctx, r.owner, r.repo, knownIssueNumber)
+		if e != nil {
+			return 0, e
+		}
+		if issue.GetState() == "open" {
+			return knownIssueNumber, nil
+		}
+	}
+	return r.findOpenIssue(fingerprint)
+}
+
+func (r *ErrorReporter) findOpenIssue(fingerprint string) (int, error) {
+	result, _, e := r.ghClient.Search.Issues(r.ctx,
+		fmt.Sprintf(`repo:%v/%v is:issue is:open in:title "Fingerprint: %v"`, r.owner, r.repo, fingerprint), nil)
+	if e != nil {
+		return 0, e
+	}
+	for _, issue := range result.Issues {
+		if strings.Contains(issue.GetTitle(), fingerprint) {
+			return issue.GetNumber(), nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *ErrorReporter) allowedByRateLimit(now time.Time) bool {
+	if now.Sub(r.rateLimitStart) >= r.RateLimitPeriod {
+		r.rateLimitStart = now
+		r.reportsInPeriod = 0
+	}
+	if r.reportsInPeriod >= r.RateLimit {
+		return false
+	}
+	r.reportsInPeriod++
+	return true
+}
+
+func (r *ErrorReporter) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+	return r.Now()
+}
+
+// Fingerprint identifies an error by its type and the functions on its stack, which is where it was created if it
+// or one of its causes carries a stack trace, and stack otherwise. Line numbers are left out, so fingerprints survive unrelated changes.
+func Fingerprint(e interface{}, stack []uintptr) string {
+	for err, isError := e.(error); isError; err, isError = unwrap(err) {
+		if withStackTrace, hasStackTrace := err.(interface{ StackTrace() errors.StackTrace }); hasStackTrace {
+			stack = nil
+			for _, frame := range withStackTrace.StackTrace() {
+				stack = append(stack, uintptr(frame))
+			}
+		}
+		e = err
+	}
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%T\n", e)
+	frames := runtime.CallersFrames(stack)
+	for i := 0; i < maxFingerprintFrames; {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			fmt.Fprintln(hash, frame.Function)
+			i++
+		}
+		if !more {
+			break
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))[:12]
+}
+
 func (r *ErrorReporter) ReportError(e error) {
 	r.ReportPanic(e, nil)
 }
 
+func unwrap(e error) (error, bool) {
+	causer, isCauser := e.(interface{ Cause() error })
+	if !isCauser || causer.Cause() == nil {
+		return nil, false
+	}
+	return causer.Cause(), true
+}
+
 func errorStringFrom(e interface{}) string {
 	if _, hasStackTrace := e.(interface{ StackTrace() errors.StackTrace }); hasStackTrace {
 		return fmt.Sprintf("STRING: %v\nSTACKTRACE:\n%+v\nINTROSPECTION:\n%v", e, e, spew.Sdump(e))
@@ -121,9 +364,12 @@ func marshalContext(context interface{}) string {
 	if context == nil {
 		return "Not available."
 	}
-	buf, e := json.MarshalIndent(context, "", "  ")
-	if e != nil {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if e := encoder.Encode(context); e != nil {
 		return "Error while marshalling context. Error: " + e.Error()
 	}
-	return string(buf)
+	return strings.TrimSuffix(buf.String(), "\n")
 }
@@ -0,0 +1,253 @@
+package github_test
+
+import (
+	"sync/atomic"
+	"time"
+
+	gogithub "github.com/google/go-github/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/async"
+	"github.com/petergtz/alexa-wikipedia/github"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/redaction"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func panicInParsing(reporter *github.ErrorReporter) {
+	defer func() {
+		if e := recover(); e != nil {
+			reporter.ReportPanic(e, map[string]string{"request": "parse"})
+		}
+	}()
+	var position interface{} = "not a number"
+	_ = position.(int)
+}
+
+func panicInRendering(reporter *github.ErrorReporter) {
+	defer func() {
+		if e := recover(); e != nil {
+			reporter.ReportPanic(e, nil)
+		}
+	}()
+	panic("some error")
+}
+
+func newUpstreamError() error {
+	return errors.New("upstream unavailable")
+}
+
+var _ = Describe("ErrorReporter", func() {
+	var (
+		githubServer *fakeGithub
+		snsServer    *fakeSNS
+		now          time.Time
+		newReporter  func() *github.ErrorReporter
+		reporter     *github.ErrorReporter
+	)
+
+	BeforeEach(func() {
+		githubServer = newFakeGithub()
+		snsServer = newFakeSNS()
+		now = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		newReporter = func() *github.ErrorReporter {
+			r := github.NewErrorReporterWithClient(githubServer.Client(), "owner", "repo", zap.NewNop().Sugar(),
+				"https://logs/%v", snsServer.Client(), "arn:aws:sns:eu-central-1:123:errors")
+			r.Now = func() time.Time { return now }
+			return r
+		}
+		reporter = newReporter()
+	})
+
+	AfterEach(func() {
+		githubServer.Close()
+		snsServer.Close()
+	})
+
+	It("creates an issue and publishes to SNS", func() {
+		panicInParsing(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(1))
+		Expect(githubServer.issues[0].GetTitle()).To(MatchRegexp(`^Internal Server Error \(Fingerprint: [0-9a-f]{12}\)$`))
+		Expect(githubServer.issues[0].GetBody()).To(ContainSubstring("https://logs/"))
+		Expect(snsServer.subjects).To(ConsistOf(MatchRegexp(`^repo: Internal Server Error \(ErrID: \d+\)$`)))
+	})
+
+	It("reports only on GitHub without SNS client", func() {
+		reporter = github.NewErrorReporterWithClient(githubServer.Client(), "owner", "repo", zap.NewNop().Sugar(), "https://logs/%v", nil, "")
+
+		panicInRendering(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(1))
+		Expect(snsServer.subjects).To(BeEmpty())
+	})
+
+	It("reports in the background with a worker, still telling errors apart by where they occurred", func() {
+		worker := async.NewWorker(10, metrics.NoOp{}, zap.NewNop().Sugar())
+		reporter.Worker = worker
+		released := make(chan struct{})
+		githubServer.onRequest = func() { <-released }
+
+		panicInParsing(reporter)
+		panicInRendering(reporter)
+
+		Expect(githubServer.issues).To(BeEmpty())
+		close(released)
+		worker.Close()
+		Expect(githubServer.issues).To(HaveLen(2))
+		Expect(githubServer.issues[0].GetTitle()).NotTo(Equal(githubServer.issues[1].GetTitle()))
+		Expect(snsServer.subjects).To(HaveLen(2))
+	})
+
+	It("redacts the error and its context before publishing", func() {
+		reporter.Redactor = redaction.NewRedactor([]byte("secret"))
+
+		func() {
+			defer func() {
+				reporter.ReportPanic(recover(), map[string]interface{}{
+					"session": map[string]interface{}{"user": map[string]string{"userId": "some-user", "accessToken": "some-token"}},
+				})
+			}()
+			panic("could not find jane@example.com")
+		}()
+
+		Expect(snsServer.messages).To(HaveLen(1))
+		Expect(snsServer.messages[0]).To(ContainSubstring("could not find <EMAIL>"))
+		Expect(snsServer.messages[0]).To(ContainSubstring(`"accessToken": "<REDACTED>"`))
+		Expect(snsServer.messages[0]).To(ContainSubstring(`"userId": "` + reporter.Redactor.(*redaction.Redactor).Hash("some-user") + `"`))
+		Expect(snsServer.messages[0]).NotTo(ContainSubstring("jane@example.com"))
+		Expect(snsServer.messages[0]).NotTo(ContainSubstring("some-user"))
+		Expect(snsServer.messages[0]).NotTo(ContainSubstring("some-token"))
+	})
+
+	It("only logs the same error again within the dedup window", func() {
+		panicInParsing(reporter)
+		now = now.Add(github.DefaultDedupWindow - time.Second)
+		panicInParsing(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(1))
+		Expect(githubServer.comments).To(BeEmpty())
+		Expect(snsServer.subjects).To(HaveLen(1))
+	})
+
+	It("comments on the open issue when the same error occurs after the dedup window", func() {
+		panicInParsing(reporter)
+		now = now.Add(github.DefaultDedupWindow / 2)
+		panicInParsing(reporter)
+		now = now.Add(github.DefaultDedupWindow)
+		panicInParsing(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(1))
+		Expect(githubServer.commentBodies(1)).To(ConsistOf(And(
+			ContainSubstring("Occurred again"),
+			ContainSubstring("Occurrences not reported since the last report: 1"))))
+		Expect(snsServer.subjects).To(HaveLen(2))
+	})
+
+	It("searches for the issue only as long as it doesn't know it", func() {
+		panicInParsing(reporter)
+		now = now.Add(github.DefaultDedupWindow)
+		panicInParsing(reporter)
+		now = now.Add(github.DefaultDedupWindow)
+		panicInParsing(reporter)
+
+		Expect(githubServer.searches).To(Equal(1))
+		Expect(githubServer.commentBodies(1)).To(HaveLen(2))
+	})
+
+	It("finds the open issue created by another function instance", func() {
+		panicInParsing(reporter)
+
+		panicInParsing(newReporter())
+
+		Expect(githubServer.issues).To(HaveLen(1))
+		Expect(githubServer.commentBodies(1)).To(HaveLen(1))
+	})
+
+	It("creates a new issue once the previous one is closed", func() {
+		panicInParsing(reporter)
+		githubServer.issues[0].State = gogithub.String("closed")
+		now = now.Add(github.DefaultDedupWindow)
+
+		panicInParsing(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(2))
+		Expect(githubServer.issues[1].GetTitle()).To(Equal(githubServer.issues[0].GetTitle()))
+	})
+
+	It("creates separate issues for different errors", func() {
+		panicInParsing(reporter)
+		panicInRendering(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(2))
+		Expect(githubServer.issues[0].GetTitle()).NotTo(Equal(githubServer.issues[1].GetTitle()))
+	})
+
+	It("limits the number of reports across all errors", func() {
+		reporter.RateLimit = 1
+		panicInParsing(reporter)
+		panicInRendering(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(1))
+		Expect(snsServer.subjects).To(HaveLen(1))
+
+		now = now.Add(github.DefaultRateLimitPeriod)
+		panicInRendering(reporter)
+
+		Expect(githubServer.issues).To(HaveLen(2))
+	})
+
+	It("retries reporting on GitHub only after a backoff when it failed", func() {
+		githubServer.unavailable = true
+		panicInParsing(reporter)
+		requests := githubServer.requests
+		Expect(requests).NotTo(BeZero())
+
+		now = now.Add(github.DefaultFailureBackoff - time.Second)
+		panicInParsing(reporter)
+		Expect(githubServer.requests).To(Equal(requests))
+
+		githubServer.unavailable = false
+		now = now.Add(time.Second)
+		panicInParsing(reporter)
+		Expect(githubServer.issues).To(HaveLen(1))
+	})
+
+	It("doesn't hold up other reports while reporting on GitHub", func() {
+		var requests int32
+		started, released := make(chan struct{}), make(chan struct{})
+		githubServer.onRequest = func() {
+			if atomic.AddInt32(&requests, 1) == 1 {
+				close(started)
+				<-released
+			}
+		}
+		upstreamError := newUpstreamError()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			reporter.ReportError(upstreamError)
+		}()
+		<-started
+
+		panicInRendering(reporter)
+		reporter.ReportError(upstreamError)
+		githubServer.mutex.Lock()
+		issuesWhileReporting := len(githubServer.issues)
+		githubServer.mutex.Unlock()
+		close(released)
+		<-done
+
+		Expect(issuesWhileReporting).To(Equal(1))
+		Expect(githubServer.issues).To(HaveLen(2))
+		Expect(githubServer.comments).To(BeEmpty())
+	})
+
+	Describe("Fingerprint", func() {
+		It("identifies errors with stack traces by where they were created", func() {
+			Expect(github.Fingerprint(newUpstreamError(), nil)).To(Equal(github.Fingerprint(errors.Wrap(newUpstreamError(), "context"), nil)))
+			Expect(github.Fingerprint(newUpstreamError(), nil)).NotTo(Equal(github.Fingerprint(errors.New("upstream unavailable"), nil)))
+		})
+	})
+})
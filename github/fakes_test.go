@@ -0,0 +1,210 @@
+package github_test
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	gogithub "github.com/google/go-github/github"
+)
+
+// fakeGithub serves issues, their comments and a search for open issues by title in repo owner/repo.
+type fakeGithub struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	issues   []*gogithub.Issue
+	comments []*fakeComment
+	// beforeEdit is called before a comment edit is applied, e.g. to simulate a concurrent edit.
+	beforeEdit func()
+	// onRequest, if set, is called before a request is handled, e.g. to make GitHub slow.
+	onRequest   func()
+	unavailable bool
+	requests    int
+	searches    int
+}
+
+type fakeComment struct {
+	gogithub.IssueComment
+	issueNumber int
+}
+
+var (
+	issuePath         = regexp.MustCompile(`^/repos/owner/repo/issues/(\d+)$`)
+	issueCommentsPath = regexp.MustCompile(`^/repos/owner/repo/issues/(\d+)/comments$`)
+	commentPath       = regexp.MustCompile(`^/repos/owner/repo/issues/comments/(\d+)$`)
+	searchedTitle     = regexp.MustCompile(`in:title "(.*)"`)
+)
+
+func newFakeGithub() *fakeGithub {
+	g := &fakeGithub{}
+	g.Server = httptest.NewServer(g)
+	return g
+}
+
+func (g *fakeGithub) Client() *gogithub.Client {
+	client := gogithub.NewClient(nil)
+	client.BaseURL, _ = url.Parse(g.URL + "/")
+	return client
+}
+
+func (g *fakeGithub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.onRequest != nil {
+		g.onRequest()
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.requests++
+	if g.unavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/owner/repo/issues":
+		var request gogithub.IssueRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(g.addIssue(request.GetTitle(), request.GetBody()))
+	case r.Method == http.MethodGet && issuePath.MatchString(r.URL.Path):
+		number, _ := strconv.Atoi(issuePath.FindStringSubmatch(r.URL.Path)[1])
+		if number < 1 || number > len(g.issues) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(g.issues[number-1])
+	case r.Method == http.MethodGet && r.URL.Path == "/search/issues":
+		g.searches++
+		var result gogithub.IssuesSearchResult
+		title := searchedTitle.FindStringSubmatch(r.URL.Query().Get("q"))[1]
+		for _, issue := range g.issues {
+			if issue.GetState() == "open" && strings.Contains(issue.GetTitle(), title) {
+				result.Issues = append(result.Issues, *issue)
+			}
+		}
+		json.NewEncoder(w).Encode(result)
+	case issueCommentsPath.MatchString(r.URL.Path):
+		issueNumber, _ := strconv.Atoi(issueCommentsPath.FindStringSubmatch(r.URL.Path)[1])
+		switch r.Method {
+		case http.MethodGet:
+			comments := []gogithub.IssueComment{}
+			for _, comment := range g.comments {
+				if comment.issueNumber == issueNumber {
+					comments = append(comments, comment.IssueComment)
+				}
+			}
+			json.NewEncoder(w).Encode(comments)
+		case http.MethodPost:
+			var comment gogithub.IssueComment
+			json.NewDecoder(r.Body).Decode(&comment)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(g.addComment(issueNumber, comment.GetBody()))
+		}
+	case commentPath.MatchString(r.URL.Path):
+		id, _ := strconv.Atoi(commentPath.FindStringSubmatch(r.URL.Path)[1])
+		comment := g.comments[id-1]
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", etagOf(comment))
+			json.NewEncoder(w).Encode(comment.IssueComment)
+		case http.MethodPatch:
+			if g.beforeEdit != nil {
+				g.beforeEdit()
+				g.beforeEdit = nil
+			}
+			// Like GitHub, edits are unconditional. If-Match is ignored.
+			var edit gogithub.IssueComment
+			json.NewDecoder(r.Body).Decode(&edit)
+			comment.Body = edit.Body
+			json.NewEncoder(w).Encode(comment.IssueComment)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (g *fakeGithub) addIssue(title, body string) *gogithub.Issue {
+	issue := &gogithub.Issue{
+		Number:  gogithub.Int(len(g.issues) + 1),
+		Title:   gogithub.String(title),
+		Body:    gogithub.String(body),
+		State:   gogithub.String("open"),
+		HTMLURL: gogithub.String(fmt.Sprintf("https://github.com/owner/repo/issues/%v", len(g.issues)+1)),
+	}
+	g.issues = append(g.issues, issue)
+	return issue
+}
+
+func (g *fakeGithub) addComment(issueNumber int, body string) *gogithub.IssueComment {
+	comment := &fakeComment{issueNumber: issueNumber, IssueComment: gogithub.IssueComment{
+		ID:   gogithub.Int64(int64(len(g.comments) + 1)),
+		Body: gogithub.String(body),
+	}}
+	g.comments = append(g.comments, comment)
+	return &comment.IssueComment
+}
+
+func (g *fakeGithub) commentBodies(issueNumber int) []string {
+	var bodies []string
+	for _, comment := range g.comments {
+		if comment.issueNumber == issueNumber {
+			bodies = append(bodies, comment.GetBody())
+		}
+	}
+	return bodies
+}
+
+// etagOf returns a weak ETag, as GitHub does.
+func etagOf(comment *fakeComment) string {
+	return fmt.Sprintf(`W/"%x"`, sha1.Sum([]byte(comment.GetBody())))
+}
+
+// fakeSNS records the messages published to it.
+type fakeSNS struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	subjects []string
+	messages []string
+}
+
+func newFakeSNS() *fakeSNS {
+	s := &fakeSNS{}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *fakeSNS) Client() *sns.SNS {
+	return sns.New(session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("eu-central-1"),
+		Endpoint:    aws.String(s.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})))
+}
+
+func (s *fakeSNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r.ParseForm()
+	if r.Form.Get("Action") != "Publish" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.subjects = append(s.subjects, r.Form.Get("Subject"))
+	s.messages = append(s.messages, r.Form.Get("Message"))
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write([]byte(`<PublishResponse xmlns="http://sns.amazonaws.com/doc/2010-03-31/">
+  <PublishResult><MessageId>message-id</MessageId></PublishResult>
+  <ResponseMetadata><RequestId>request-id</RequestId></ResponseMetadata>
+</PublishResponse>`))
+}
@@ -0,0 +1,151 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	shardMarker = "<!-- text quality findings -->"
+	// DefaultMaxShardSize stays below GitHub's limit of 65536 characters per comment.
+	DefaultMaxShardSize = 60000
+)
+
+// ShardedPersistence keeps findings in comments on an issue, one finding per line. It only ever adds comments with the
+// findings no comment has yet and never edits one: GitHub doesn't support conditional edits, so concurrent function
+// instances could overwrite each other's findings otherwise. Concurrent instances may add the same finding twice,
+// though. Other comments on the issue stay alone.
+type ShardedPersistence struct {
+	ghClient     *github.Client
+	ctx          context.Context
+	owner        string
+	repo         string
+	issueNumber  int
+	maxShardSize int
+}
+
+func NewShardedPersistence(owner, repo string, issueNumber int, token string) *ShardedPersistence {
+	ctx := context.TODO()
+	return NewShardedPersistenceWithClient(
+		github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))),
+		owner, repo, issueNumber, DefaultMaxShardSize)
+}
+
+func NewShardedPersistenceWithClient(ghClient *github.Client, owner, repo string, issueNumber int, maxShardSize int) *ShardedPersistence {
+	return &ShardedPersistence{
+		ghClient:     ghClient,
+		ctx:          context.TODO(),
+		owner:        owner,
+		repo:         repo,
+		issueNumber:  issueNumber,
+		maxShardSize: maxShardSize,
+	}
+}
+
+func (p *ShardedPersistence) Persist(findings []string) error {
+	e := p.tryPersist(findings)
+	switch {
+	case isTempOrTimeoutError(errors.Cause(e)):
+		return nil // do not report, as there is no point in doing so. TODO: emit metrics about this.
+	case e != nil:
+		return errors.Wrap(e, "Could not persist findings on Github")
+	}
+	return nil
+}
+
+func (p *ShardedPersistence) tryPersist(findings []string) error {
+	shards, e := p.shards()
+	if e != nil {
+		return e
+	}
+	missing := missingLines(shards, findings)
+	for len(missing) > 0 {
+		fitting := p.fitting(missing)
+		if fitting == 0 {
+			fitting = 1 // a finding longer than a shard gets a comment of its own
+		}
+		e = retryTempOrTimeoutErrors(func() error {
+			_, _, e := p.ghClient.Issues.CreateComment(p.ctx, p.owner, p.repo, p.issueNumber, &github.IssueComment{
+				Body: github.String(shardBody(missing[:fitting])),
+			})
+			return e
+		})
+		if e != nil {
+			return errors.Wrap(e, "Could not create comment")
+		}
+		missing = missing[fitting:]
+	}
+	return nil
+}
+
+func (p *ShardedPersistence) shards() ([]*github.IssueComment, error) {
+	var shards []*github.IssueComment
+	options := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		var (
+			comments []*github.IssueComment
+			response *github.Response
+		)
+		e := retryTempOrTimeoutErrors(func() error {
+			var e error
+			comments, response, e = p.ghClient.Issues.ListComments(p.ctx, p.owner, p.repo, p.issueNumber, options)
+			return e
+		})
+		if e != nil {
+			return nil, errors.Wrap(e, "Could not list comments")
+		}
+		for _, comment := range comments {
+			if strings.HasPrefix(comment.GetBody(), shardMarker) {
+				shards = append(shards, comment)
+			}
+		}
+		if response.NextPage == 0 {
+			return shards, nil
+		}
+		options.Page = response.NextPage
+	}
+}
+
+// fitting is how many of lines fit into one shard.
+func (p *ShardedPersistence) fitting(lines []string) int {
+	for i := len(lines); i > 0; i-- {
+		if len(shardBody(lines[:i])) <= p.maxShardSize {
+			return i
+		}
+	}
+	return 0
+}
+
+func missingLines(shards []*github.IssueComment, findings []string) []string {
+	existing := make(map[string]bool)
+	for _, shard := range shards {
+		for _, line := range shardLines(shard.GetBody()) {
+			existing[line] = true
+		}
+	}
+	var missing []string
+	for _, finding := range findings {
+		line := strings.ReplaceAll(finding, "\n", `\n`)
+		if !existing[line] {
+			existing[line] = true
+			missing = append(missing, line)
+		}
+	}
+	return missing
+}
+
+func shardBody(lines []string) string {
+	return shardMarker + "\n```\n" + strings.Join(lines, "\n") + "\n```"
+}
+
+func shardLines(body string) []string {
+	body = strings.Trim(strings.ReplaceAll(strings.TrimPrefix(body, shardMarker), "```", ""), "\n\r")
+	if body == "" {
+		return nil
+	}
+	return linebreakPattern.Split(body, -1)
+}
@@ -0,0 +1,89 @@
+package github_test
+
+import (
+	"strings"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/github"
+)
+
+func shard(lines ...string) string {
+	return "<!-- text quality findings -->\n```\n" + strings.Join(lines, "\n") + "\n```"
+}
+
+var _ = Describe("ShardedPersistence", func() {
+	var (
+		fake        *fakeGithub
+		persistence *github.ShardedPersistence
+	)
+
+	BeforeEach(func() {
+		fake = newFakeGithub()
+		persistence = github.NewShardedPersistenceWithClient(fake.Client(), "owner", "repo", 1, len(shard("a.B", "c.D")))
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("creates a shard when there is none", func() {
+		fake.addComment(1, "Some discussion")
+
+		Expect(persistence.Persist([]string{"a.B", "c.D"})).To(Succeed())
+
+		Expect(fake.commentBodies(1)).To(Equal([]string{"Some discussion", shard("a.B", "c.D")}))
+	})
+
+	It("keeps findings on one line", func() {
+		Expect(persistence.Persist([]string{"a\nB"})).To(Succeed())
+
+		Expect(fake.commentBodies(1)).To(Equal([]string{shard(`a\nB`)}))
+	})
+
+	It("adds only findings no shard has yet", func() {
+		fake.addComment(1, shard("a.B", "c.D"))
+		fake.addComment(1, shard("e.F"))
+
+		Expect(persistence.Persist([]string{"c.D", "g.H", "e.F", "g.H"})).To(Succeed())
+
+		Expect(fake.commentBodies(1)).To(Equal([]string{shard("a.B", "c.D"), shard("e.F"), shard("g.H")}))
+	})
+
+	It("doesn't edit anything when there are no new findings", func() {
+		fake.addComment(1, shard("a.B"))
+		fake.beforeEdit = func() { Fail("must not edit") }
+
+		Expect(persistence.Persist([]string{"a.B"})).To(Succeed())
+	})
+
+	It("spreads findings over as many shards as needed", func() {
+		Expect(persistence.Persist([]string{"a.B", "c.D", "e.F", "g.H", "i.J"})).To(Succeed())
+
+		Expect(fake.commentBodies(1)).To(Equal([]string{shard("a.B", "c.D"), shard("e.F", "g.H"), shard("i.J")}))
+	})
+
+	It("gives a finding longer than a shard its own shard", func() {
+		Expect(persistence.Persist([]string{"a very long finding that fits nowhere"})).To(Succeed())
+
+		Expect(fake.commentBodies(1)).To(Equal([]string{shard("a very long finding that fits nowhere")}))
+	})
+
+	It("never edits shards, so concurrent function instances can't overwrite each other's findings", func() {
+		fake.addComment(1, shard("a.B"))
+		fake.beforeEdit = func() { Fail("must not edit") }
+		other := github.NewShardedPersistenceWithClient(fake.Client(), "owner", "repo", 1, len(shard("a.B", "c.D")))
+		var requests int32
+		fake.onRequest = func() {
+			// The second request is creating the comment, after listing the shards
+			if atomic.AddInt32(&requests, 1) == 2 {
+				Expect(other.Persist([]string{"x.Y"})).To(Succeed())
+			}
+		}
+
+		Expect(persistence.Persist([]string{"c.D"})).To(Succeed())
+
+		Expect(fake.commentBodies(1)).To(ConsistOf(shard("a.B"), shard("x.Y"), shard("c.D")))
+	})
+})
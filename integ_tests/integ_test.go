@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -29,17 +30,33 @@ func TestEndToEnd(t *testing.T) {
 	ginkgo.RunSpecs(t, "EndToEnd")
 }
 
-var skill alexa.Skill
+var logger *zap.SugaredLogger
+
+// skillFor creates a skill that answers requests to Wikipedia from the fixture's cassette, so the suite doesn't need
+// network. Set WIKIPEDIA_MODE=live to test against the real Wikipedia instead. To update fixtures and cassettes, use
+// cmd/record-fixtures. Fixtures without a cassette fail in replay mode.
+func skillFor(fixturename string) *factory.Skill {
+	_, filename, _, _ := runtime.Caller(0)
+	config := factory.OfflineConfig()
+	config.WikipediaMode = factory.WikipediaModeReplay
+	if os.Getenv("WIKIPEDIA_MODE") != "" {
+		config.WikipediaMode = factory.WikipediaMode(os.Getenv("WIKIPEDIA_MODE"))
+	}
+	config.WikipediaCassette = filepath.Join(filepath.Dir(filename), "cassettes", fixturename)
+	if config.WikipediaMode == factory.WikipediaModeReplay {
+		if _, e := os.Stat(config.WikipediaCassette); os.IsNotExist(e) {
+			Fail("no cassette recorded for " + fixturename + ". Record it using cmd/record-fixtures.")
+		}
+	}
+	Expect(config.Validate()).To(Succeed())
+	return factory.CreateSkillFrom(config, logger)
+}
 
 var _ = Describe("Skill", func() {
 	BeforeSuite(func() {
-		logger, e := zap.NewDevelopment()
+		l, e := zap.NewDevelopment()
 		Expect(e).NotTo(HaveOccurred())
-		skill = factory.CreateSkill(logger.Sugar())
-	})
-
-	AfterSuite(func() {
-		time.Sleep(3 * time.Second) // Make sure worker queues can finish work
+		logger = l.Sugar()
 	})
 
 	_, filename, _, _ := runtime.Caller(0)
@@ -67,6 +84,9 @@ var _ = Describe("Skill", func() {
 					e = json.Unmarshal(c, &requestEnv)
 					Expect(e).NotTo(HaveOccurred())
 
+					skill := skillFor(fileInfo.Name())
+					defer skill.Close()
+
 					Expect(json.MarshalIndent(skill.ProcessRequest(&requestEnv), "", "  ")).To(MatchJSON(stringFrom(strings.Replace(fileInfo.Name(), "json", "response.json", -1))))
 				})
 			})
@@ -0,0 +1,46 @@
+// Package interactions provides alternatives to the DynamoDB-backed interaction logger and history of go-alexa.
+package interactions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/petergtz/go-alexa"
+)
+
+// InMemoryStore keeps interactions only for the lifetime of the process. It's meant for local use and tests.
+type InMemoryStore struct {
+	mutex        sync.Mutex
+	interactions []*alexa.Interaction
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+func (s *InMemoryStore) Log(interaction *alexa.Interaction) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.interactions = append(s.interactions, interaction)
+}
+
+func (s *InMemoryStore) GetInteractionsByUser(userID string, newerThan time.Time) []*alexa.Interaction {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var result []*alexa.Interaction
+	for _, interaction := range s.interactions {
+		if interaction.UserID == userID && interaction.Timestamp.After(newerThan) {
+			result = append(result, interaction)
+		}
+	}
+	return result
+}
+
+// NoOpStore forgets all interactions.
+type NoOpStore struct{}
+
+func (NoOpStore) Log(*alexa.Interaction) {}
+
+func (NoOpStore) GetInteractionsByUser(userID string, newerThan time.Time) []*alexa.Interaction {
+	return nil
+}
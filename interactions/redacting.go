@@ -0,0 +1,50 @@
+package interactions
+
+import (
+	"time"
+
+	"github.com/petergtz/alexa-wikipedia/redaction"
+	"github.com/petergtz/go-alexa"
+)
+
+// Store is what the skill needs from an interaction backend.
+type Store interface {
+	alexa.InteractionLogger
+	alexa.InteractionHistory
+}
+
+// unredactedAttributes don't come from what the user said. ActualTitle is a Wikipedia title and must stay unchanged,
+// because the skill compares it with the titles it finds later on. Titles like "1984 (1956)" would look like phone
+// numbers otherwise.
+var unredactedAttributes = map[string]bool{"Intent": true, "ActualTitle": true}
+
+// RedactingStore logs interactions to Store with hashed user and session IDs and redacted attributes. It looks up
+// interactions by all hashes the user ID had in the requested time range.
+type RedactingStore struct {
+	Store    Store
+	Redactor *redaction.Redactor
+}
+
+func (s *RedactingStore) Log(interaction *alexa.Interaction) {
+	redacted := *interaction
+	redacted.UserID = s.Redactor.Hash(interaction.UserID)
+	redacted.SessionID = s.Redactor.Hash(interaction.SessionID)
+	if interaction.Attributes != nil {
+		redacted.Attributes = make(map[string]interface{}, len(interaction.Attributes))
+		for key, value := range interaction.Attributes {
+			if text, isText := value.(string); isText && !unredactedAttributes[key] {
+				value = s.Redactor.Text(text)
+			}
+			redacted.Attributes[key] = value
+		}
+	}
+	s.Store.Log(&redacted)
+}
+
+func (s *RedactingStore) GetInteractionsByUser(userID string, newerThan time.Time) []*alexa.Interaction {
+	var result []*alexa.Interaction
+	for _, hash := range s.Redactor.HashesSince(userID, newerThan) {
+		result = append(result, s.Store.GetInteractionsByUser(hash, newerThan)...)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package interactions_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/interactions"
+	"github.com/petergtz/alexa-wikipedia/redaction"
+	"github.com/petergtz/go-alexa"
+)
+
+var _ = Describe("RedactingStore", func() {
+	var (
+		now      time.Time
+		redactor *redaction.Redactor
+		backend  *interactions.InMemoryStore
+		store    *interactions.RedactingStore
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2020, 1, 1, 23, 59, 55, 0, time.UTC)
+		redactor = redaction.NewRedactor([]byte("secret"))
+		redactor.Now = func() time.Time { return now }
+		backend = interactions.NewInMemoryStore()
+		store = &interactions.RedactingStore{Store: backend, Redactor: redactor}
+	})
+
+	It("logs interactions with hashed IDs and redacted attributes", func() {
+		store.Log(&alexa.Interaction{
+			RequestID:  "some-request",
+			UserID:     "some-user",
+			SessionID:  "some-session",
+			Timestamp:  now,
+			Attributes: map[string]interface{}{"Intent": "DefineIntent", "SearchQuery": "jane@example.com", "Count": 1},
+		})
+
+		Expect(backend.GetInteractionsByUser(redactor.Hash("some-user"), now.Add(-time.Minute))).To(Equal([]*alexa.Interaction{{
+			RequestID:  "some-request",
+			UserID:     redactor.Hash("some-user"),
+			SessionID:  redactor.Hash("some-session"),
+			Timestamp:  now,
+			Attributes: map[string]interface{}{"Intent": "DefineIntent", "SearchQuery": "<EMAIL>", "Count": 1},
+		}}))
+		Expect(backend.GetInteractionsByUser("some-user", now.Add(-time.Minute))).To(BeEmpty())
+	})
+
+	It("keeps titles, even if they look like phone numbers", func() {
+		store.Log(&alexa.Interaction{
+			UserID:     "some-user",
+			Timestamp:  now,
+			Attributes: map[string]interface{}{"SearchQuery": "1984 (1956)", "ActualTitle": "1984 (1956)"},
+		})
+
+		interactions := store.GetInteractionsByUser("some-user", now.Add(-time.Minute))
+
+		Expect(interactions).To(HaveLen(1))
+		Expect(interactions[0].Attributes).To(Equal(map[string]interface{}{"SearchQuery": "<PHONE>)", "ActualTitle": "1984 (1956)"}))
+	})
+
+	It("finds interactions logged with an earlier salt", func() {
+		store.Log(&alexa.Interaction{RequestID: "before rotation", UserID: "some-user", Timestamp: now})
+		now = now.Add(10 * time.Second)
+		store.Log(&alexa.Interaction{RequestID: "after rotation", UserID: "some-user", Timestamp: now})
+
+		interactions := store.GetInteractionsByUser("some-user", now.Add(-time.Minute))
+
+		Expect(interactions).To(HaveLen(2))
+		Expect(interactions[0].RequestID).To(Equal("after rotation"))
+		Expect(interactions[1].RequestID).To(Equal("before rotation"))
+	})
+})
@@ -20,6 +20,27 @@ CouldNotFindSpelledTerm = "Den buchstabierten Begriff {{.SpelledTerm}} konnte ic
 InternalError = "Es ist ein interner Fehler aufgetreten bei der Benutzung von Wikipedia."
 EndOfArticle = "Oh! Wir sind bereits am Ende angelangt. Wenn Du noch einen weiteren Artikel vorgelesen kriegen möchtest, sage z.B. \"Suche nach Elefant\"."
 SpellingHint = "Ich habe den Artikel, \"{{.Title}}\", gerade erst gelesen. Falls ich nicht Deinen gewünschten Artikel gefunden habe, unterbrich mich und sage: \"Alexa, Suche buchstabieren\", um Deine Suchanfrage zu buchstabieren. Hier ist der Artikel:"
+AlreadyAtBeginning = "Wir sind bereits am Anfang des Artikels."
+YouAreInIntroduction = "Wir sind in der Einleitung von \"{{.ArticleTitle}}\", ungefähr {{.Percentage}} Prozent des Artikels sind gelesen."
+YouAreInSection = "Wir sind in Abschnitt {{.SectionNumber}}, {{.SectionTitle}}, ungefähr {{.Percentage}} Prozent des Artikels sind gelesen."
+HereIsTheSummary = "Hier ist die Zusammenfassung:"
+PreferenceSaved = "Alles klar, ich habe mir das gemerkt."
+ReadingShorterParts = "Alles klar, ab jetzt lese ich kürzere Teile vor."
+ReadingLongerParts = "Alles klar, ab jetzt lese ich längere Teile vor."
+CardAttribution = "Quelle: Wikipedia, {{.URL}}. Der Text ist unter der Lizenz CC BY-SA 4.0 verfügbar."
+PleaseAnswerYesOrNo = "Das habe ich nicht verstanden. Soll ich weiterlesen? Bitte antworte mit Ja oder Nein."
+WhichSectionToJumpExample = "Zu welchem Abschnitt möchtest Du springen? Sage z.B. \"Springe zu Abschnitt 2\"."
+NoJumpShouldIContinue = "Okay. Soll ich stattdessen weiterlesen?"
+NotSupportedForArticles = "Das geht bei Wikipedia-Artikeln leider nicht."
+OfferToResume = "Willkommen zurück bei Wikipedia. Beim letzten Mal haben wir über \"{{.Word}}\" gelesen. Soll ich dort weiterlesen?"
+RepromptShouldContinue1 = "Soll ich weiterlesen?"
+RepromptShouldContinue2 = "Möchtest Du, dass ich weiterlese? Sage einfach Ja oder Nein."
+RepromptShouldContinue3 = "Sage \"Weiter\", um weiterzulesen, oder \"Stopp\", um aufzuhören."
+RepromptJumpWhere1 = "Zu welchem Abschnitt möchtest Du springen?"
+RepromptJumpWhere2 = "Sage z.B. \"Springe zu Abschnitt 2\"."
+RepromptJumpWhere3 = "Nenne die Nummer oder den Titel eines Abschnitts, zu dem ich springen soll."
+RepromptShouldResume1 = "Soll ich dort weiterlesen, wo wir aufgehört haben?"
+RepromptShouldResume2 = "Sage Ja, um weiterzulesen, oder suche nach einem neuen Begriff."
 `)
 
 	EnUs = []byte(`
@@ -41,6 +62,27 @@ CouldNotFindSpelledTerm = "I could not find the spelled term \"{{.SpelledTerm}}\
 InternalError = "An internal error occurred while using My encyclopdia."
 EndOfArticle = "Oh, we've already reached the end of the article. Why don't you try a different search. E.g. say \"What is an elephant?\""
 SpellingHint = "I've just read the article \"{{.Title}}\" already. Did I not find what you were looking for? If so, interrupt me and say \"Alexa, spell search\" to spell your search query. Here's the article:"
+AlreadyAtBeginning = "We're already at the beginning of the article."
+YouAreInIntroduction = "You're in the introduction of \"{{.ArticleTitle}}\", about {{.Percentage}} percent through the article."
+YouAreInSection = "You're in section {{.SectionNumber}}, {{.SectionTitle}}, about {{.Percentage}} percent through the article."
+HereIsTheSummary = "Here's the summary:"
+PreferenceSaved = "Alright, I'll remember that."
+ReadingShorterParts = "Alright, from now on I'll read shorter parts."
+ReadingLongerParts = "Alright, from now on I'll read longer parts."
+CardAttribution = "Source: Wikipedia, {{.URL}}. Text is available under the CC BY-SA 4.0 license."
+PleaseAnswerYesOrNo = "Sorry, I didn't get that. Shall I continue? Please answer with yes or no."
+WhichSectionToJumpExample = "Which section do you want to go to? Say e.g. \"Jump to section 2\"."
+NoJumpShouldIContinue = "Okay. Shall I continue reading instead?"
+NotSupportedForArticles = "Sorry, that's not possible with Wikipedia articles."
+OfferToResume = "Welcome back to Wikipedia. Last time we were reading about \"{{.Word}}\". Shall I continue where we left off?"
+RepromptShouldContinue1 = "Shall I continue?"
+RepromptShouldContinue2 = "Would you like me to keep reading? Just say yes or no."
+RepromptShouldContinue3 = "Say \"next\" to keep reading, or \"stop\" to finish."
+RepromptJumpWhere1 = "Which section do you want to go to?"
+RepromptJumpWhere2 = "Say e.g. \"Jump to section 2\"."
+RepromptJumpWhere3 = "Tell me the number or the title of the section you want to go to."
+RepromptShouldResume1 = "Shall I continue where we left off?"
+RepromptShouldResume2 = "Say yes to keep reading, or search for something new."
 `)
 
 	EsEs = []byte(`
@@ -62,5 +104,26 @@ CouldNotFindSpelledTerm = "No puedo encontrar el término deletreado \"{{.Spelle
 InternalError = "Ha ocurrido un error interno mientras se usaba Mi enciclopedia."
 EndOfArticle = "Vaya, ya hemos llegado al final del artículo. ¿Porqué no pruebas una búsqueda diferente? Por ejemplo, di \"¿Qué es un elefante?\""
 SpellingHint = "Acabo de leer el artículo \"{{.Title}}\" ya. ¿No es lo que estabas buscando? Si es éso, interrúmpeme y di \"Alexa, deletrear búsqueda\" para deletrearlo. Aquí tienes el artículo:"
+AlreadyAtBeginning = "Ya estamos al principio del artículo."
+YouAreInIntroduction = "Estás en la introducción de \"{{.ArticleTitle}}\", aproximadamente al {{.Percentage}} por ciento del artículo."
+YouAreInSection = "Estás en la sección {{.SectionNumber}}, {{.SectionTitle}}, aproximadamente al {{.Percentage}} por ciento del artículo."
+HereIsTheSummary = "Aquí tienes el resumen:"
+PreferenceSaved = "Vale, lo recordaré."
+ReadingShorterParts = "Vale, a partir de ahora leeré partes más cortas."
+ReadingLongerParts = "Vale, a partir de ahora leeré partes más largas."
+CardAttribution = "Fuente: Wikipedia, {{.URL}}. El texto está disponible bajo la licencia CC BY-SA 4.0."
+PleaseAnswerYesOrNo = "Perdona, no te he entendido. ¿Continúo? Por favor, responde sí o no."
+WhichSectionToJumpExample = "¿A qué sección quieres ir? Di por ejemplo \"Salta a la sección 2\"."
+NoJumpShouldIContinue = "Vale. ¿Continúo leyendo?"
+NotSupportedForArticles = "Lo siento, eso no es posible con artículos de Wikipedia."
+OfferToResume = "Bienvenido de nuevo a Wikipedia. La última vez estábamos leyendo sobre \"{{.Word}}\". ¿Continúo donde lo dejamos?"
+RepromptShouldContinue1 = "¿Continúo?"
+RepromptShouldContinue2 = "¿Quieres que siga leyendo? Simplemente di sí o no."
+RepromptShouldContinue3 = "Di \"siguiente\" para seguir leyendo, o \"para\" para terminar."
+RepromptJumpWhere1 = "¿A qué sección quieres ir?"
+RepromptJumpWhere2 = "Di por ejemplo \"Salta a la sección 2\"."
+RepromptJumpWhere3 = "Dime el número o el título de la sección a la que quieres ir."
+RepromptShouldResume1 = "¿Continúo donde lo dejamos?"
+RepromptShouldResume2 = "Di sí para seguir leyendo, o busca algo nuevo."
 `)
 )
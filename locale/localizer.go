@@ -56,6 +56,10 @@ func (l *Localizer) Spell(number int) string {
 	return numbers[l.lang][number]
 }
 
+func (l *Localizer) Lang() string {
+	return l.lang
+}
+
 func (l *Localizer) WikiEndpoint() string {
 	return endpoints[l.lang]
 }
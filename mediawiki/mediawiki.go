@@ -1,6 +1,7 @@
 package mediawiki
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -15,6 +16,8 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/tracing"
 	"github.com/petergtz/alexa-wikipedia/wiki"
 )
 
@@ -23,12 +26,21 @@ type WikiPagePreProcessor interface{ Process(*Page) *Page }
 type MediaWiki struct {
 	Logger               *zap.SugaredLogger
 	WikiPagePreProcessor WikiPagePreProcessor
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Metrics records the latency and status of requests to Wikipedia. Defaults to metrics.NoOp.
+	Metrics metrics.Metrics
 }
 
 type Page struct {
 	Title   string
 	Extract string
 	Missing bool
+	// Locale is the locale the page was requested for, e.g. "de-DE".
+	Locale    string `json:"-"`
+	Thumbnail struct {
+		Source string
+	}
 }
 
 type ExtractQuery struct {
@@ -64,55 +76,92 @@ type SearchQuery struct {
 	}
 }
 
-func (mw *MediaWiki) GetPage(word string, localizer *locale.Localizer) (wiki.Page, error) {
-	return mw.getPage("titles="+url.QueryEscape(strings.Title(word)), localizer)
+func (mw *MediaWiki) GetPage(ctx context.Context, word string, localizer *locale.Localizer) (wiki.Page, error) {
+	ctx, span := tracing.Start(ctx, "mediawiki.GetPage")
+	defer span.End()
+	page, e := mw.getPage(ctx, "titles="+url.QueryEscape(strings.Title(word)), localizer)
+	span.RecordError(e)
+	return page, e
 }
 
-func (mw *MediaWiki) SearchPage(word string, localizer *locale.Localizer) (wiki.Page, error) {
+func (mw *MediaWiki) SearchPage(ctx context.Context, word string, localizer *locale.Localizer) (page wiki.Page, e error) {
+	ctx, span := tracing.Start(ctx, "mediawiki.SearchPage")
+	defer func() {
+		span.RecordError(e)
+		span.End()
+	}()
 	var search SearchQuery
-	e := makeJsonRequest("https://"+localizer.WikiEndpoint()+"/w/api.php?format=json&action=query&list=search&srsearch="+url.QueryEscape(word)+"&srprop=&utf8=&srlimit=1", &search, mw.Logger)
+	e = mw.makeJsonRequest(ctx, "https://"+localizer.WikiEndpoint()+"/w/api.php?format=json&action=query&list=search&srsearch="+url.QueryEscape(word)+"&srprop=&utf8=&srlimit=1", &search)
 	if e != nil {
 		return wiki.Page{}, e
 	}
 	if len(search.Query.Search) == 0 {
 		return wiki.Page{}, errors.New("Page not found on Wikipedia")
 	}
-	return mw.getPage("pageids="+strconv.Itoa(search.Query.Search[0].Pageid), localizer)
+	return mw.getPage(ctx, "pageids="+strconv.Itoa(search.Query.Search[0].Pageid), localizer)
 }
 
-func (mw *MediaWiki) getPage(query string, localizer *locale.Localizer) (wiki.Page, error) {
+func (mw *MediaWiki) getPage(ctx context.Context, query string, localizer *locale.Localizer) (wiki.Page, error) {
 	var extract ExtractQuery
-	e := makeJsonRequest("https://"+localizer.WikiEndpoint()+"/w/api.php?format=json&action=query&prop=extracts&"+query+"&redirects=true&formatversion=2&explaintext=true&exlimit=1", &extract, mw.Logger)
+	e := mw.makeJsonRequest(ctx, "https://"+localizer.WikiEndpoint()+"/w/api.php?format=json&action=query&prop=extracts%7Cpageimages&"+query+"&redirects=true&formatversion=2&explaintext=true&exlimit=1&piprop=thumbnail&pithumbsize=1200", &extract)
 	if e != nil {
 		return wiki.Page{}, e
 	}
 	if extract.Query.Pages[0].Missing {
 		return wiki.Page{}, errors.New("Page not found on Wikipedia")
 	}
-	return WikiPageFrom(mw.WikiPagePreProcessor.Process(&extract.Query.Pages[0]), localizer), nil
+	page := &extract.Query.Pages[0]
+	page.Locale = localizer.Lang()
+	_, span := tracing.Start(ctx, "preprocess")
+	defer span.End()
+	return WikiPageFrom(mw.WikiPagePreProcessor.Process(page), localizer), nil
 }
 
-func makeJsonRequest(url string, data interface{}, logger *zap.SugaredLogger) error {
-	logger = logger.With("url", url)
+func (mw *MediaWiki) makeJsonRequest(ctx context.Context, requestURL string, data interface{}) error {
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	// The query contains what users searched for, so only host and path end up in logs and errors.
+	logger := mw.Logger.With("host", request.URL.Host, "path", request.URL.Path)
 	logger.Debug("Before http Get")
 	startTime := time.Now()
-	request, _ := http.NewRequest(http.MethodGet, url, nil)
+	_, httpSpan := tracing.Start(ctx, "http GET")
+	httpSpan.SetAttribute("host", request.URL.Host)
 	request.Header.Add("User-Agent", "Alexa_MyEncyclopedia_Bot/1.0 (https://github.com/petergtz/alexa-wikipedia/)")
-	r, e := http.DefaultClient.Do(request)
+	client := mw.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r, e := client.Do(request)
 	logger.Debugw("After http Get", "duration", time.Since(startTime).String())
+	if urlError, isURLError := e.(*url.Error); isURLError {
+		e = urlError.Err
+	}
 	if e != nil {
-		return errors.Wrapf(e, "Could not request url: \"%v\"", url)
+		mw.metrics().Duration("upstream_request_duration", time.Since(startTime), metrics.Dimensions{"host": request.URL.Host, "status": "error"})
+		httpSpan.RecordError(e)
+		httpSpan.End()
+		return errors.Wrapf(e, "Could not request url: \"%v%v\"", request.URL.Host, request.URL.Path)
 	}
 	logger.Debug("Before read body")
-	startTime = time.Now()
+	readStartTime := time.Now()
 	content, e := ioutil.ReadAll(r.Body)
-	logger.Debugw("After read body", "duration", time.Since(startTime).String(), "body-size", len(content))
+	logger.Debugw("After read body", "duration", time.Since(readStartTime).String(), "body-size", len(content))
+	status := strconv.Itoa(r.StatusCode)
+	if e != nil {
+		status = "error"
+	}
+	mw.metrics().Duration("upstream_request_duration", time.Since(startTime), metrics.Dimensions{"host": request.URL.Host, "status": status})
+	httpSpan.SetAttribute("status", status)
+	httpSpan.RecordError(e)
+	httpSpan.End()
 	if e != nil {
 		return errors.Wrap(e, "Could not read body of page")
 	}
 	logger.Debug("Before json Unmarhsal")
 	startTime = time.Now()
+	_, parseSpan := tracing.Start(ctx, "parse JSON")
 	e = json.Unmarshal(content, data)
+	parseSpan.RecordError(e)
+	parseSpan.End()
 	logger.Debugw("After json Unmarshal", "duration", time.Since(startTime).String())
 	if e != nil {
 		return errors.Wrapf(e, "Could not unmarshal body of page. body was: \"%v\"", content)
@@ -120,11 +169,19 @@ func makeJsonRequest(url string, data interface{}, logger *zap.SugaredLogger) er
 	return nil
 }
 
+func (mw *MediaWiki) metrics() metrics.Metrics {
+	if mw.Metrics == nil {
+		return metrics.NoOp{}
+	}
+	return mw.Metrics
+}
+
 func WikiPageFrom(mediawikipage *Page, localizer *locale.Localizer) wiki.Page {
 	page := wiki.Page{
 		Title: mediawikipage.Title,
 		// It's not obvious, but suffixing a \n to the text helps with regexes below
-		Body: mediawikipage.Extract + "\n",
+		Body:     mediawikipage.Extract + "\n",
+		ImageURL: mediawikipage.Thumbnail.Source,
 	}
 	parse((*wiki.Section)(&page), 2, localizer)
 	return page
@@ -1,14 +1,20 @@
 package mediawiki_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 
 	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/tracing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -40,25 +46,25 @@ var _ = Describe("Mediawiki", func() {
 	})
 
 	It("returns the page even when it's not an exact match", func() {
-		page, e := mediaWiki.SearchPage("Der Baum", localizer)
+		page, e := mediaWiki.SearchPage(context.Background(), "Der Baum", localizer)
 		Expect(e).NotTo(HaveOccurred())
 		Expect(page.Title).To(Equal("Baum"))
 	})
 
 	It("returns the page when it finds it", func() {
-		page, e := mediaWiki.GetPage("Baum", localizer)
+		page, e := mediaWiki.GetPage(context.Background(), "Baum", localizer)
 		Expect(e).NotTo(HaveOccurred())
 		Expect(page.Title).To(Equal("Baum"))
 	})
 
 	It("returns an error when it cannot find the page", func() {
-		_, e := mediaWiki.GetPage("NotExistingWikiPage", localizer)
+		_, e := mediaWiki.GetPage(context.Background(), "NotExistingWikiPage", localizer)
 		Expect(e).To(HaveOccurred())
 		Expect(e.Error()).To(Equal("Page not found on Wikipedia"))
 	})
 
 	It("properly escapes wearch words", func() {
-		page, e := mediaWiki.GetPage("Albert Einstein", localizer)
+		page, e := mediaWiki.GetPage(context.Background(), "Albert Einstein", localizer)
 		Expect(e).NotTo(HaveOccurred())
 		Expect(page.Title).To(Equal("Albert Einstein"))
 	})
@@ -283,3 +289,68 @@ var _ = Describe("Mediawiki", func() {
 		})
 	})
 })
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) { return f(request) }
+
+var _ = Describe("Upstream metrics", func() {
+	It("records the latency of requests to Wikipedia by host and status", func() {
+		recorder := metrics.NewPrometheus(metrics.DefaultBuckets)
+		mediaWiki := &mediawiki.MediaWiki{
+			Logger:               zap.NewNop().Sugar(),
+			WikiPagePreProcessor: &noOpWikiPagePreprocessor{},
+			Metrics:              recorder,
+			HTTPClient: &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				if strings.Contains(request.URL.RawQuery, "Timeout") {
+					return nil, errors.New("timeout")
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"query":{"pages":[{"title":"Baum","extract":"Ein Baum."}]}}`)),
+				}, nil
+			})},
+		}
+		localizer := locale.NewLocalizer(i18n.NewBundle(language.English), "de-DE", zap.NewNop().Sugar())
+
+		_, e := mediaWiki.GetPage(context.Background(), "Baum", localizer)
+		Expect(e).NotTo(HaveOccurred())
+		_, e = mediaWiki.GetPage(context.Background(), "Timeout", localizer)
+		Expect(e).To(HaveOccurred())
+
+		Expect(recorder.Text()).To(ContainSubstring(`upstream_request_duration_seconds_count{host="de.wikipedia.org",status="200"} 1` + "\n"))
+		Expect(recorder.Text()).To(ContainSubstring(`upstream_request_duration_seconds_count{host="de.wikipedia.org",status="error"} 1` + "\n"))
+	})
+})
+
+var _ = Describe("Tracing", func() {
+	It("traces the request to Wikipedia, parsing and preprocessing as children of the span in the context", func() {
+		exporter := &tracing.InMemoryExporter{}
+		mediaWiki := &mediawiki.MediaWiki{
+			Logger:               zap.NewNop().Sugar(),
+			WikiPagePreProcessor: &noOpWikiPagePreprocessor{},
+			HTTPClient: &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"query":{"pages":[{"title":"Baum","extract":"Ein Baum."}]}}`)),
+				}, nil
+			})},
+		}
+		localizer := locale.NewLocalizer(i18n.NewBundle(language.English), "de-DE", zap.NewNop().Sugar())
+		ctx, root := tracing.NewTracer(exporter).Start(context.Background(), "root")
+
+		_, e := mediaWiki.GetPage(ctx, "Baum", localizer)
+		root.End()
+
+		Expect(e).NotTo(HaveOccurred())
+		spans := exporter.Spans()
+		var names []string
+		for _, span := range spans {
+			names = append(names, span.Name)
+		}
+		Expect(names).To(Equal([]string{"http GET", "parse JSON", "preprocess", "mediawiki.GetPage", "root"}))
+		Expect(spans[0].Attributes).To(Equal(map[string]interface{}{"host": "de.wikipedia.org", "status": "200"}))
+		Expect(spans[0].ParentSpanID).To(Equal(spans[3].SpanID))
+		Expect(spans[3].ParentSpanID).To(Equal(spans[4].SpanID))
+	})
+})
@@ -3,39 +3,53 @@ package mediawiki
 import (
 	"regexp"
 
+	"github.com/petergtz/alexa-wikipedia/async"
 	"github.com/pkg/math"
+	"go.uber.org/zap"
 )
 
 type Persistence interface{ Persist([]string) error }
 
+// AsyncPersistence persists in the background using Worker, so slow persistences don't delay responses. Errors go to
+// Logger.
+type AsyncPersistence struct {
+	Persistence Persistence
+	Worker      *async.Worker
+	Logger      *zap.SugaredLogger
+}
+
+func (p *AsyncPersistence) Persist(findings []string) error {
+	p.Worker.Enqueue(func() {
+		if e := p.Persistence.Persist(findings); e != nil {
+			p.Logger.Errorw("Could not persist findings", "error", e)
+		}
+	})
+	return nil
+}
+
 type ErrorReporter interface {
 	ReportPanic(interface{}, interface{})
 }
 
 type HighlightMissingSpacesNaivelyWikiPagePreProcessor struct {
 	Persistence   Persistence
-	pageChan      chan *Page
+	worker        *async.Worker
 	errorReporter ErrorReporter
 }
 
-func NewHighlightMissingSpacesNaivelyWikiPagePreProcessor(persistence Persistence, errorReporter ErrorReporter) *HighlightMissingSpacesNaivelyWikiPagePreProcessor {
-	pp := &HighlightMissingSpacesNaivelyWikiPagePreProcessor{
+func NewHighlightMissingSpacesNaivelyWikiPagePreProcessor(persistence Persistence, worker *async.Worker, errorReporter ErrorReporter) *HighlightMissingSpacesNaivelyWikiPagePreProcessor {
+	return &HighlightMissingSpacesNaivelyWikiPagePreProcessor{
 		Persistence:   persistence,
-		pageChan:      make(chan *Page, 100),
+		worker:        worker,
 		errorReporter: errorReporter,
 	}
-	go func() {
-		for page := range pp.pageChan {
-			pp.doProcess(page)
-		}
-	}()
-	return pp
 }
 
 var pattern = regexp.MustCompile(`[a-z]\.[A-Z]`)
 
+// Process looks for missing spaces in the background. If too many pages are waiting for that, page is skipped.
 func (pp *HighlightMissingSpacesNaivelyWikiPagePreProcessor) Process(page *Page) *Page {
-	pp.pageChan <- page
+	pp.worker.Enqueue(func() { pp.doProcess(page) })
 	return page
 }
 
@@ -0,0 +1,90 @@
+package mediawiki_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/async"
+	"github.com/petergtz/alexa-wikipedia/mediawiki"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"go.uber.org/zap"
+)
+
+// blockingPersistence signals entered and blocks in Persist until release is closed.
+type blockingPersistence struct {
+	entered chan struct{}
+	release chan struct{}
+
+	mutex    sync.Mutex
+	findings [][]string
+}
+
+func (p *blockingPersistence) Persist(findings []string) error {
+	p.entered <- struct{}{}
+	<-p.release
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.findings = append(p.findings, findings)
+	return nil
+}
+
+type panicReporter struct{ panics []interface{} }
+
+func (r *panicReporter) ReportPanic(e interface{}, context interface{}) {
+	r.panics = append(r.panics, e)
+}
+
+var _ = Describe("Background text quality", func() {
+	var persistence *blockingPersistence
+
+	BeforeEach(func() {
+		persistence = &blockingPersistence{entered: make(chan struct{}, 1000), release: make(chan struct{})}
+	})
+
+	Describe("HighlightMissingSpacesNaivelyWikiPagePreProcessor", func() {
+		It("doesn't block while persisting and counts pages it had to skip", func() {
+			recorder := metrics.NewPrometheus(metrics.DefaultBuckets)
+			worker := async.NewWorker(100, recorder, zap.NewNop().Sugar())
+			preProcessor := mediawiki.NewHighlightMissingSpacesNaivelyWikiPagePreProcessor(persistence, worker, &panicReporter{})
+			page := &mediawiki.Page{Extract: "Das ist ein Satz.Das auch."}
+
+			Expect(preProcessor.Process(page)).To(BeIdenticalTo(page))
+			<-persistence.entered
+			for i := 0; i < 200; i++ {
+				Expect(preProcessor.Process(page)).To(BeIdenticalTo(page))
+			}
+			close(persistence.release)
+			worker.Close()
+
+			Expect(persistence.findings).To(HaveLen(101), "one being persisted and a full queue")
+			Expect(persistence.findings[0]).To(ConsistOf("st ein Satz.Das auch."))
+			Expect(recorder.Text()).To(ContainSubstring(`background_jobs_dropped_total{reason="queue_full"} 100` + "\n"))
+		})
+
+		It("reports failures to persist", func() {
+			reporter := &panicReporter{}
+			worker := async.NewWorker(10, metrics.NoOp{}, zap.NewNop().Sugar())
+			preProcessor := mediawiki.NewHighlightMissingSpacesNaivelyWikiPagePreProcessor(failingPersistence{}, worker, reporter)
+
+			preProcessor.Process(&mediawiki.Page{Extract: "Das ist ein Satz.Das auch."})
+			worker.Close()
+
+			Expect(reporter.panics).To(HaveLen(1))
+		})
+	})
+
+	Describe("AsyncPersistence", func() {
+		It("returns before persisting", func() {
+			worker := async.NewWorker(10, metrics.NoOp{}, zap.NewNop().Sugar())
+			asyncPersistence := &mediawiki.AsyncPersistence{Persistence: persistence, Worker: worker, Logger: zap.NewNop().Sugar()}
+
+			Expect(asyncPersistence.Persist([]string{"finding"})).To(Succeed())
+			Expect(persistence.findings).To(BeEmpty())
+
+			close(persistence.release)
+			worker.Close()
+			Expect(persistence.findings).To(Equal([][]string{{"finding"}}))
+		})
+	})
+})
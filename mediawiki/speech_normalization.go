@@ -0,0 +1,479 @@
+package mediawiki
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SpeechNormalizer is a WikiPagePreProcessor that rewrites numbers, units, abbreviations, date ranges, Roman numerals
+// and pronunciations in extracts the way a person would read them out. The rules depend on the page's Locale. Pages
+// in languages without rules stay unchanged.
+type SpeechNormalizer struct{}
+
+func (SpeechNormalizer) Process(page *Page) *Page {
+	result := *page
+	result.Extract = NormalizeForSpeech(page.Extract, page.Locale)
+	return &result
+}
+
+type unit struct{ symbol, singular, plural string }
+
+type abbreviation struct {
+	short, long string
+	// onlyBeforeNumber is for abbreviations that are ambiguous otherwise, like "c." for circa.
+	onlyBeforeNumber bool
+	// mayEndSentence keeps the abbreviation's period when it also ends the sentence.
+	mayEndSentence bool
+}
+
+type speechRules struct {
+	thousandsSeparators string
+	rangeWord           string
+	units               []unit
+	abbreviations       []abbreviation
+	// romanNumeral expresses the Roman numeral n after a name, as in "Louis XIV".
+	romanNumeral func(n int) string
+	// romanNumeralNeedsPeriod is for languages that write Roman numerals after names as ordinals, e.g. "Ludwig XIV.".
+	// The period is only kept where it also ends the sentence.
+	romanNumeralNeedsPeriod bool
+	// singleLetterRomanNumerals allows "I", "V" and "X" as Roman numerals, as in "Karl V.". As capitals on their own are
+	// mostly initials or names like "Plan B", they are only read as numerals when followed by a period.
+	singleLetterRomanNumerals bool
+	// cardinalRomanNumeralWords are words after which Roman numerals are read as cardinals, as in "World War II", and
+	// centuries, as in "siglo XIX".
+	cardinalRomanNumeralWords []string
+	// coordinatingWords join Roman numerals after cardinal words, as in "siglos XVI y XVII". Dashes and rangeWord do,
+	// too.
+	coordinatingWords []string
+
+	unitPattern                    *regexp.Regexp
+	abbreviationPatterns           []*regexp.Regexp
+	romanNumeralPattern            *regexp.Regexp
+	coordinatedRomanNumeralPattern *regexp.Regexp
+}
+
+var speechRulesByLanguage = map[string]*speechRules{
+	"de": {
+		thousandsSeparators: ".\u00a0\u202f",
+		rangeWord:           "bis",
+		units: []unit{
+			{"km²", "Quadratkilometer", "Quadratkilometer"},
+			{"m²", "Quadratmeter", "Quadratmeter"},
+			{"km/h", "Kilometer pro Stunde", "Kilometer pro Stunde"},
+			{"°C", "Grad Celsius", "Grad Celsius"},
+			{"°F", "Grad Fahrenheit", "Grad Fahrenheit"},
+			{"km", "Kilometer", "Kilometer"},
+			{"cm", "Zentimeter", "Zentimeter"},
+			{"mm", "Millimeter", "Millimeter"},
+			{"kg", "Kilogramm", "Kilogramm"},
+			{"ha", "Hektar", "Hektar"},
+			{"m", "Meter", "Meter"},
+			{"%", "Prozent", "Prozent"},
+		},
+		abbreviations: []abbreviation{
+			{short: "ca.", long: "circa"},
+			{short: "bzw.", long: "beziehungsweise"},
+			{short: "z. B.", long: "zum Beispiel"},
+			{short: "d. h.", long: "das heißt"},
+			{short: "u. a.", long: "unter anderem", mayEndSentence: true},
+			{short: "usw.", long: "und so weiter", mayEndSentence: true},
+			{short: "etc.", long: "et cetera", mayEndSentence: true},
+			{short: "v. Chr.", long: "vor Christus", mayEndSentence: true},
+			{short: "n. Chr.", long: "nach Christus", mayEndSentence: true},
+			{short: "Jh.", long: "Jahrhundert", mayEndSentence: true},
+			{short: "sog.", long: "sogenannte"},
+			{short: "Nr.", long: "Nummer", onlyBeforeNumber: true},
+		},
+		romanNumeral:              func(n int) string { return "der " + germanOrdinal(n) },
+		romanNumeralNeedsPeriod:   true,
+		singleLetterRomanNumerals: true,
+		coordinatingWords:         []string{"und"},
+	},
+	"en": {
+		thousandsSeparators: ",",
+		rangeWord:           "to",
+		units: []unit{
+			{"km²", "square kilometer", "square kilometers"},
+			{"sq mi", "square mile", "square miles"},
+			{"m²", "square meter", "square meters"},
+			{"km/h", "kilometer per hour", "kilometers per hour"},
+			{"mph", "mile per hour", "miles per hour"},
+			{"°C", "degree Celsius", "degrees Celsius"},
+			{"°F", "degree Fahrenheit", "degrees Fahrenheit"},
+			{"km", "kilometer", "kilometers"},
+			{"cm", "centimeter", "centimeters"},
+			{"mm", "millimeter", "millimeters"},
+			{"kg", "kilogram", "kilograms"},
+			{"mi", "mile", "miles"},
+			{"ft", "foot", "feet"},
+			{"m", "meter", "meters"},
+			{"%", "percent", "percent"},
+		},
+		abbreviations: []abbreviation{
+			{short: "c.", long: "circa", onlyBeforeNumber: true},
+			{short: "ca.", long: "circa", onlyBeforeNumber: true},
+			{short: "approx.", long: "approximately"},
+			{short: "e.g.", long: "for example"},
+			{short: "i.e.", long: "that is"},
+			{short: "etc.", long: "et cetera", mayEndSentence: true},
+			{short: "vs.", long: "versus"},
+			{short: "No.", long: "number", onlyBeforeNumber: true},
+		},
+		romanNumeral:              func(n int) string { return "the " + englishOrdinal(n) },
+		cardinalRomanNumeralWords: []string{"war", "part", "type", "chapter", "volume", "act", "class", "phase"},
+		coordinatingWords:         []string{"and"},
+	},
+	"es": {
+		thousandsSeparators: ".\u00a0\u202f",
+		rangeWord:           "a",
+		units: []unit{
+			{"km²", "kilómetro cuadrado", "kilómetros cuadrados"},
+			{"m²", "metro cuadrado", "metros cuadrados"},
+			{"km/h", "kilómetro por hora", "kilómetros por hora"},
+			{"°C", "grado Celsius", "grados Celsius"},
+			{"°F", "grado Fahrenheit", "grados Fahrenheit"},
+			{"km", "kilómetro", "kilómetros"},
+			{"cm", "centímetro", "centímetros"},
+			{"mm", "milímetro", "milímetros"},
+			{"kg", "kilogramo", "kilogramos"},
+			{"ha", "hectárea", "hectáreas"},
+			{"m", "metro", "metros"},
+			{"%", "por ciento", "por ciento"},
+		},
+		abbreviations: []abbreviation{
+			{short: "c.", long: "circa", onlyBeforeNumber: true},
+			{short: "ca.", long: "circa", onlyBeforeNumber: true},
+			{short: "aprox.", long: "aproximadamente"},
+			{short: "p. ej.", long: "por ejemplo"},
+			{short: "etc.", long: "etcétera", mayEndSentence: true},
+			{short: "a. C.", long: "antes de Cristo", mayEndSentence: true},
+			{short: "d. C.", long: "después de Cristo", mayEndSentence: true},
+		},
+		romanNumeral: func(n int) string {
+			// Spanish uses ordinals for the first ten and cardinals after that, e.g. "Felipe segundo", "Alfonso trece".
+			if n <= len(spanishOrdinals) {
+				return spanishOrdinals[n-1]
+			}
+			return strconv.Itoa(n)
+		},
+		singleLetterRomanNumerals: true,
+		cardinalRomanNumeralWords: []string{"siglo", "siglos", "parte", "tomo", "tipo", "capítulo", "acto", "fase"},
+		coordinatingWords:         []string{"y", "e"},
+	},
+}
+
+var spanishOrdinals = []string{"primero", "segundo", "tercero", "cuarto", "quinto", "sexto", "séptimo", "octavo", "noveno", "décimo"}
+
+var (
+	germanOrdinals = []string{"erste", "zweite", "dritte", "vierte", "fünfte", "sechste", "siebte", "achte", "neunte", "zehnte",
+		"elfte", "zwölfte", "dreizehnte", "vierzehnte", "fünfzehnte", "sechzehnte", "siebzehnte", "achtzehnte", "neunzehnte"}
+	germanUnits = []string{"", "ein", "zwei", "drei", "vier", "fünf", "sechs", "sieben", "acht", "neun"}
+	germanTens  = []string{"zwanzig", "dreißig", "vierzig", "fünfzig", "sechzig", "siebzig", "achtzig", "neunzig"}
+)
+
+// germanOrdinal spells out n up to 99 as part of a name, e.g. "Vierzehnte" as in "Ludwig der Vierzehnte". Unlike
+// "14.", this leaves the period free to end the sentence.
+func germanOrdinal(n int) string {
+	ordinal := ""
+	switch {
+	case n < 20:
+		ordinal = germanOrdinals[n-1]
+	case n%10 == 0:
+		ordinal = germanTens[n/10-2] + "ste"
+	default:
+		ordinal = germanUnits[n%10] + "und" + germanTens[n/10-2] + "ste"
+	}
+	r, size := utf8.DecodeRuneInString(ordinal)
+	return string(unicode.ToUpper(r)) + ordinal[size:]
+}
+
+func init() {
+	for _, rules := range speechRulesByLanguage {
+		var symbols []string
+		for _, unit := range rules.units {
+			symbols = append(symbols, regexp.QuoteMeta(unit.symbol))
+		}
+		rules.unitPattern = regexp.MustCompile(`(\d+(?:[.,]\d+)?)[ \x{00A0}\x{202F}]?(` + strings.Join(symbols, "|") + `)`)
+		for _, abbreviation := range rules.abbreviations {
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(abbreviation.short), " ", " ?")
+			switch {
+			case abbreviation.onlyBeforeNumber:
+				pattern += `( ?)\d`
+			case abbreviation.mayEndSentence:
+				pattern += `( *$|\s+\p{Lu})?`
+			}
+			rules.abbreviationPatterns = append(rules.abbreviationPatterns, regexp.MustCompile(`(?m)`+pattern))
+		}
+		coordination := `(, | ?[–-] ?|,? (?:` + strings.Join(append(rules.coordinatingWords, rules.rangeWord), "|") + `) )([IVXLCDM]+)\b`
+		rules.coordinatedRomanNumeralPattern = regexp.MustCompile(coordination)
+		rules.romanNumeralPattern = regexp.MustCompile(`(\pL+) ([IVXLCDM]+)\b(\.?)((?:` + coordination + `)*)`)
+	}
+}
+
+// NormalizeForSpeech applies the speech rules of locale's language to text.
+func NormalizeForSpeech(text string, locale string) string {
+	rules, exist := speechRulesByLanguage[strings.Split(locale, "-")[0]]
+	if !exist {
+		return text
+	}
+	text = stripPronunciations(text)
+	text = rules.joinThousands(text)
+	text = rules.expandUnits(text)
+	text = rules.expandAbbreviations(text)
+	text = rules.expandRanges(text)
+	text = rules.expandRomanNumerals(text)
+	return text
+}
+
+var (
+	innermostBrackets = regexp.MustCompile(`\s*\(([^()]*)\)`)
+	ipaTranscription  = regexp.MustCompile(`\s*\[[^\]\n]*[\x{0250}-\x{02AF}ˈˌː][^\]\n]*\]`)
+	ipaCharacter      = regexp.MustCompile(`[\x{0250}-\x{02AF}ˈˌː]`)
+
+	pronunciationMarkers = []string{"ipa", "listen", "pronunciation", "anhören", "aussprache", "escuchar", "pronunciación"}
+)
+
+// stripPronunciations removes IPA transcriptions and hints at audio files. Inside brackets, only the parts separated by
+// ";" that are about pronunciation go, so e.g. birth dates stay.
+func stripPronunciations(text string) string {
+	for previous := ""; previous != text; {
+		previous = text
+		text = innermostBrackets.ReplaceAllStringFunc(text, func(brackets string) string {
+			var kept []string
+			content := innermostBrackets.FindStringSubmatch(brackets)[1]
+			for _, part := range strings.Split(content, ";") {
+				if !isAboutPronunciation(part) {
+					kept = append(kept, strings.TrimSpace(part))
+				}
+			}
+			switch {
+			case len(kept) == 0:
+				return ""
+			case len(kept) == len(strings.Split(content, ";")):
+				return brackets
+			default:
+				return brackets[:strings.Index(brackets, "(")] + "(" + strings.Join(kept, "; ") + ")"
+			}
+		})
+	}
+	return ipaTranscription.ReplaceAllString(text, "")
+}
+
+func isAboutPronunciation(text string) bool {
+	if ipaCharacter.MatchString(text) {
+		return true
+	}
+	text = strings.ToLower(strings.TrimSpace(text))
+	for _, marker := range pronunciationMarkers {
+		if strings.HasPrefix(text, marker) && !startsWithLetterOrDigit(text[len(marker):]) {
+			return true
+		}
+	}
+	return false
+}
+
+var groupedNumber = regexp.MustCompile(`\d{1,3}(?:[.,\x{00A0}\x{202F}]\d{3})+`)
+
+func (rules *speechRules) joinThousands(text string) string {
+	return replaceMatches(groupedNumber, text, func(match []int) (string, bool) {
+		number := text[match[0]:match[1]]
+		if endsWithNumber(text[:match[0]]) || startsWithDigit(text[match[1]:]) ||
+			strings.IndexFunc(number, func(r rune) bool { return !unicode.IsDigit(r) && !strings.ContainsRune(rules.thousandsSeparators, r) }) != -1 {
+			return "", false
+		}
+		return strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, number), true
+	})
+}
+
+func (rules *speechRules) expandUnits(text string) string {
+	return replaceMatches(rules.unitPattern, text, func(match []int) (string, bool) {
+		if endsWithNumber(text[:match[0]]) || startsWithLetterOrDigit(text[match[1]:]) {
+			return "", false
+		}
+		number, symbol := text[match[2]:match[3]], text[match[4]:match[5]]
+		for _, unit := range rules.units {
+			if unit.symbol == symbol {
+				if number == "1" {
+					return number + " " + unit.singular, true
+				}
+				return number + " " + unit.plural, true
+			}
+		}
+		return "", false
+	})
+}
+
+func (rules *speechRules) expandAbbreviations(text string) string {
+	for i, pattern := range rules.abbreviationPatterns {
+		abbreviation := rules.abbreviations[i]
+		text = replaceMatches(pattern, text, func(match []int) (string, bool) {
+			if endsWithLetter(text[:match[0]]) {
+				return "", false
+			}
+			switch {
+			case abbreviation.onlyBeforeNumber:
+				return abbreviation.long + " " + text[match[3]:match[1]], true
+			case abbreviation.mayEndSentence && match[2] != -1:
+				return abbreviation.long + "." + text[match[2]:match[3]], true
+			}
+			return abbreviation.long, true
+		})
+	}
+	return text
+}
+
+var numberRange = regexp.MustCompile(`(\d{1,4})([–-])(\d{1,4})`)
+
+func (rules *speechRules) expandRanges(text string) string {
+	return replaceMatches(numberRange, text, func(match []int) (string, bool) {
+		from, dash, to := text[match[2]:match[3]], text[match[4]:match[5]], text[match[6]:match[7]]
+		if endsWithNumber(text[:match[0]]) || startsWithNumber(text[match[1]:]) || (dash == "-" && (len(from) != 4 || len(to) != 4)) {
+			// A hyphen could be anything else, so only years are safe
+			return "", false
+		}
+		return from + " " + rules.rangeWord + " " + to, true
+	})
+}
+
+var (
+	validRomanNumeral = regexp.MustCompile(`^M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+	// singleLetterRomanNumeral leaves out "L", "C", "D" and "M", which are hardly ever used as numerals on their own.
+	singleLetterRomanNumeral = regexp.MustCompile(`^[IVX]$`)
+	sentenceStart            = regexp.MustCompile(`^(\s*$|\s+\p{Lu})`)
+)
+
+func (rules *speechRules) expandRomanNumerals(text string) string {
+	return replaceMatches(rules.romanNumeralPattern, text, func(match []int) (string, bool) {
+		word, numeral, period := text[match[2]:match[3]], text[match[4]:match[5]], text[match[6]:match[7]]
+		coordinated := text[match[8]:match[9]]
+		if startsWithLetterOrDigit(text[match[5]:]) || !validRomanNumeral.MatchString(numeral) ||
+			(len(numeral) == 1 && (!rules.singleLetterRomanNumerals || !singleLetterRomanNumeral.MatchString(numeral) || period == "")) {
+			return "", false
+		}
+		n := romanNumeralValue(numeral)
+		for _, cardinalWord := range rules.cardinalRomanNumeralWords {
+			if strings.ToLower(word) == cardinalWord {
+				return word + " " + strconv.Itoa(n) + period + rules.expandCoordinatedRomanNumerals(coordinated, text[match[1]:]), true
+			}
+		}
+		// Names are hardly ever followed by numerals of 100 and above. These are rather years, as in "Jahr MCMXXXIX.".
+		if r, _ := utf8.DecodeRuneInString(word); !unicode.IsUpper(r) || n >= 100 {
+			return "", false
+		}
+		if rules.romanNumeralNeedsPeriod {
+			if period == "" {
+				return "", false
+			}
+			if coordinated != "" || !sentenceStart.MatchString(text[match[1]:]) {
+				period = ""
+			}
+		}
+		return word + " " + rules.romanNumeral(n) + period + coordinated, true
+	})
+}
+
+// expandCoordinatedRomanNumerals expands the Roman numerals in coordinated, e.g. " y XVII" in "siglos XVI y XVII",
+// unless one of them isn't valid or rest continues the last one.
+func (rules *speechRules) expandCoordinatedRomanNumerals(coordinated string, rest string) string {
+	if coordinated == "" || startsWithLetterOrDigit(rest) {
+		return coordinated
+	}
+	var result strings.Builder
+	for _, match := range rules.coordinatedRomanNumeralPattern.FindAllStringSubmatch(coordinated, -1) {
+		separator, numeral := match[1], match[2]
+		if !validRomanNumeral.MatchString(numeral) || (len(numeral) == 1 && !singleLetterRomanNumeral.MatchString(numeral)) {
+			return coordinated
+		}
+		if strings.TrimSpace(separator) == "–" || strings.TrimSpace(separator) == "-" {
+			separator = " " + rules.rangeWord + " "
+		}
+		result.WriteString(separator + strconv.Itoa(romanNumeralValue(numeral)))
+	}
+	return result.String()
+}
+
+func romanNumeralValue(numeral string) int {
+	values := map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+	result := 0
+	for i := range numeral {
+		if i+1 < len(numeral) && values[numeral[i]] < values[numeral[i+1]] {
+			result -= values[numeral[i]]
+		} else {
+			result += values[numeral[i]]
+		}
+	}
+	return result
+}
+
+func englishOrdinal(n int) string {
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+		return strconv.Itoa(n) + "th"
+	case n%10 == 1:
+		return strconv.Itoa(n) + "st"
+	case n%10 == 2:
+		return strconv.Itoa(n) + "nd"
+	case n%10 == 3:
+		return strconv.Itoa(n) + "rd"
+	default:
+		return strconv.Itoa(n) + "th"
+	}
+}
+
+// replaceMatches replaces the matches of pattern for which replace returns true. replace gets the indexes of the match
+// and its submatches as returned by FindAllStringSubmatchIndex.
+func replaceMatches(pattern *regexp.Regexp, text string, replace func(match []int) (string, bool)) string {
+	var result strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		replacement, ok := replace(match)
+		if !ok {
+			continue
+		}
+		result.WriteString(text[last:match[0]])
+		result.WriteString(replacement)
+		last = match[1]
+	}
+	result.WriteString(text[last:])
+	return result.String()
+}
+
+// endsWithNumber is true if text ends with a digit, optionally followed by a decimal or thousands separator.
+func endsWithNumber(text string) bool {
+	r, size := utf8.DecodeLastRuneInString(text)
+	if strings.ContainsRune(".,", r) {
+		r, _ = utf8.DecodeLastRuneInString(text[:len(text)-size])
+	}
+	return unicode.IsDigit(r)
+}
+
+// startsWithNumber is true if text starts with a digit, optionally preceded by a decimal or thousands separator.
+func startsWithNumber(text string) bool {
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, ",") {
+		text = text[1:]
+	}
+	return startsWithDigit(text)
+}
+
+func startsWithDigit(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsDigit(r)
+}
+
+func startsWithLetterOrDigit(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func endsWithLetter(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return unicode.IsLetter(r)
+}
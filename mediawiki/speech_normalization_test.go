@@ -0,0 +1,48 @@
+package mediawiki_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/mediawiki"
+)
+
+var _ = Describe("Text quality", func() {
+	Describe("SpeechNormalizer", func() {
+		// Set UPDATE_GOLDEN_FILES=true to write the current output to the golden files after changing rules. Review
+		// the diff before committing it.
+		DescribeTable("normalizes the golden texts",
+			func(locale string) {
+				input, e := ioutil.ReadFile(filepath.Join("testdata", "speech", locale+".txt"))
+				Expect(e).NotTo(HaveOccurred())
+				goldenPath := filepath.Join("testdata", "speech", locale+".golden.txt")
+
+				result := mediawiki.SpeechNormalizer{}.Process(&mediawiki.Page{Extract: string(input), Locale: locale})
+
+				if os.Getenv("UPDATE_GOLDEN_FILES") == "true" {
+					Expect(ioutil.WriteFile(goldenPath, []byte(result.Extract), 0644)).To(Succeed())
+				}
+				golden, e := ioutil.ReadFile(goldenPath)
+				Expect(e).NotTo(HaveOccurred())
+				Expect(result.Extract).To(Equal(string(golden)))
+			},
+			Entry("de-DE", "de-DE"),
+			Entry("en-US", "en-US"),
+			Entry("es-ES", "es-ES"),
+		)
+
+		It("uses the rules of the locale's language", func() {
+			Expect(mediawiki.NormalizeForSpeech("It is 5 km long.", "en-GB")).To(Equal("It is 5 kilometers long."))
+			Expect(mediawiki.NormalizeForSpeech("Es ist 5 km lang.", "de-DE")).To(Equal("Es ist 5 Kilometer lang."))
+		})
+
+		It("leaves pages in languages without rules alone", func() {
+			Expect(mediawiki.NormalizeForSpeech("Il est 5 km de long.", "fr-FR")).To(Equal("Il est 5 km de long."))
+			Expect(mediawiki.NormalizeForSpeech("It is 5 km long.", "")).To(Equal("It is 5 km long."))
+		})
+	})
+})
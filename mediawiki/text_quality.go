@@ -0,0 +1,132 @@
+package mediawiki
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/pkg/math"
+	"go.uber.org/zap"
+)
+
+// PreProcessorChain is a WikiPagePreProcessor that runs its elements one after another.
+type PreProcessorChain []WikiPagePreProcessor
+
+func (c PreProcessorChain) Process(page *Page) *Page {
+	for _, preProcessor := range c {
+		page = preProcessor.Process(page)
+	}
+	return page
+}
+
+// TextFixer is a WikiPagePreProcessor that fixes one kind of problem in extracts, so they read well. Passages Fix
+// finds suspicious, but cannot fix, are handed to Findings, if set.
+type TextFixer struct {
+	Fix      func(text string) (fixed string, findings []string)
+	Findings Persistence
+	Logger   *zap.SugaredLogger
+}
+
+func (f *TextFixer) Process(page *Page) *Page {
+	fixed, findings := f.Fix(page.Extract)
+	if len(findings) > 0 && f.Findings != nil {
+		if e := f.Findings.Persist(findings); e != nil && f.Logger != nil {
+			f.Logger.Errorw("Could not persist findings", "error", e, "title", page.Title)
+		}
+	}
+	result := *page
+	result.Extract = fixed
+	return &result
+}
+
+// NewTextQualityPipeline fixes all problems known to make extracts sound bad when read aloud. Speech normalization
+// runs before FixDoubleSpaces, which cleans up after it.
+func NewTextQualityPipeline(findings Persistence, logger *zap.SugaredLogger) PreProcessorChain {
+	return PreProcessorChain{
+		&TextFixer{Fix: FixTemplateArtifacts, Findings: findings, Logger: logger},
+		&TextFixer{Fix: FixReferenceMarkers, Findings: findings, Logger: logger},
+		&TextFixer{Fix: FixMissingSpaces, Findings: findings, Logger: logger},
+		SpeechNormalizer{},
+		&TextFixer{Fix: FixDoubleSpaces, Findings: findings, Logger: logger},
+	}
+}
+
+var (
+	template                = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+	punctuationOnlyBrackets = regexp.MustCompile(`\(\s*[,;:]*\s*\)`)
+	leadingPunctuation      = regexp.MustCompile(`\(\s*[,;:]\s*`)
+	templateRemainder       = regexp.MustCompile(`\{\{|\}\}|\{\||\|\}`)
+)
+
+// FixTemplateArtifacts removes templates the extracts API didn't expand and the empty brackets they leave behind,
+// e.g. "Berlin ( ; † 1900)" becomes "Berlin († 1900)". Unbalanced template braces are findings.
+func FixTemplateArtifacts(text string) (string, []string) {
+	for previous := ""; previous != text; {
+		previous = text
+		text = template.ReplaceAllString(text, "")
+	}
+	text = punctuationOnlyBrackets.ReplaceAllString(text, "")
+	text = leadingPunctuation.ReplaceAllString(text, "(")
+	return text, passagesAround(text, templateRemainder)
+}
+
+var (
+	referenceMarker = regexp.MustCompile(`(?i)[ \t]*\[(\d+|[a-z]|(Anm\.|Note|Nota|Notes?) ?\d+|citation needed|Quelle fehlt|Nachweis fehlt|cita requerida)\]`)
+	squareBrackets  = regexp.MustCompile(`\[[^\]\n]{0,30}\]`)
+)
+
+// FixReferenceMarkers removes markers like "[1]" or "[citation needed]". Other short passages in square brackets are
+// findings, because they may or may not be meant to be read.
+func FixReferenceMarkers(text string) (string, []string) {
+	text = referenceMarker.ReplaceAllString(text, "")
+	return text, passagesAround(text, squareBrackets)
+}
+
+var (
+	missingSpace         = regexp.MustCompile(`(\p{Ll}{2,}[.!?])(\p{Lu}\p{Ll})`)
+	possiblyMissingSpace = regexp.MustCompile(`\p{Ll}\.\p{Lu}`)
+)
+
+// FixMissingSpaces inserts spaces between sentences like "Ende.Anfang", which MediaWiki produces when it strips
+// markup between them. Cases that might also be abbreviations, like "e.V.", are findings.
+func FixMissingSpaces(text string) (string, []string) {
+	text = missingSpace.ReplaceAllString(text, "$1 $2")
+	return text, passagesAround(text, possiblyMissingSpace)
+}
+
+var (
+	multipleSpaces         = regexp.MustCompile(`[ \t\x{00A0}]{2,}`)
+	spacesAtLineEnd        = regexp.MustCompile(`(?m)[ \t\x{00A0}]+$`)
+	spaceBeforePunctuation = regexp.MustCompile(`(\pL|\pN|[)\]]) ([.,;:!?])(\s|$)`)
+)
+
+// FixDoubleSpaces collapses runs of spaces and removes spaces at line ends and before punctuation, which are mostly
+// left over from removing other artifacts. It has no findings.
+func FixDoubleSpaces(text string) (string, []string) {
+	text = multipleSpaces.ReplaceAllString(text, " ")
+	text = spacesAtLineEnd.ReplaceAllString(text, "")
+	text = spaceBeforePunctuation.ReplaceAllString(text, "$1$2$3")
+	return text, nil
+}
+
+func passagesAround(text string, pattern *regexp.Regexp) []string {
+	var passages []string
+	for _, index := range pattern.FindAllStringIndex(text, -1) {
+		start, end := math.MaxInt(index[0]-10, 0), math.MinInt(index[1]+10, len(text))
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+		passages = append(passages, text[start:end])
+	}
+	return passages
+}
+
+// LoggingPersistence logs findings instead of persisting them.
+type LoggingPersistence struct{ Logger *zap.SugaredLogger }
+
+func (p *LoggingPersistence) Persist(findings []string) error {
+	p.Logger.Infow("Text quality findings", "findings", findings)
+	return nil
+}
@@ -0,0 +1,90 @@
+package mediawiki_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/alexa-wikipedia/mediawiki"
+)
+
+type findingsCollector struct{ findings []string }
+
+func (c *findingsCollector) Persist(findings []string) error {
+	c.findings = append(c.findings, findings...)
+	return nil
+}
+
+type failingPersistence struct{}
+
+func (failingPersistence) Persist([]string) error { return errors.New("some error") }
+
+var _ = Describe("Text quality", func() {
+	expectFixed := func(fix func(string) (string, []string)) func(string, string, []string) {
+		return func(text string, expectedText string, expectedFindings []string) {
+			fixed, findings := fix(text)
+			Expect(fixed).To(Equal(expectedText))
+			Expect(findings).To(Equal(expectedFindings))
+		}
+	}
+
+	DescribeTable("FixTemplateArtifacts", expectFixed(mediawiki.FixTemplateArtifacts),
+		Entry("leaves clean text alone", "Berlin ist eine Stadt.", "Berlin ist eine Stadt.", nil),
+		Entry("removes templates", "Berlin{{Audio|De-Berlin.ogg}} ist eine Stadt.", "Berlin ist eine Stadt.", nil),
+		Entry("removes nested templates", "Berlin{{Lang|de|{{IPA|bɛʁˈliːn}}}} ist", "Berlin ist", nil),
+		Entry("removes brackets left empty", "Berlin ( ; ) ist eine Stadt.", "Berlin  ist eine Stadt.", nil),
+		Entry("removes leading punctuation in brackets", "Goethe ( ; † 1832 in Weimar)", "Goethe († 1832 in Weimar)", nil),
+		Entry("reports unbalanced braces", "Die Tabelle {| class=wikitable", "Die Tabelle {| class=wikitable", []string{"e Tabelle {| class=wik"}),
+	)
+
+	DescribeTable("FixReferenceMarkers", expectFixed(mediawiki.FixReferenceMarkers),
+		Entry("leaves clean text alone", "Berlin ist eine Stadt.", "Berlin ist eine Stadt.", nil),
+		Entry("removes numbered markers", "Berlin ist eine Stadt.[1][23] Sie", "Berlin ist eine Stadt. Sie", nil),
+		Entry("removes lettered and note markers", "eine Stadt [a] mit Fluss[Anm. 2]", "eine Stadt mit Fluss", nil),
+		Entry("removes maintenance markers", "a city[citation needed] and", "a city and", nil),
+		Entry("is case-insensitive", "a city[Citation Needed] and", "a city and", nil),
+		Entry("reports other square brackets", "Er sagte [sic] es.", "Er sagte [sic] es.", []string{"Er sagte [sic] es."}),
+		Entry("keeps headings intact", "Text.\n== Geschichte ==\nMehr", "Text.\n== Geschichte ==\nMehr", nil),
+	)
+
+	DescribeTable("FixMissingSpaces", expectFixed(mediawiki.FixMissingSpaces),
+		Entry("leaves clean text alone", "Berlin ist eine Stadt. Sie liegt", "Berlin ist eine Stadt. Sie liegt", nil),
+		Entry("inserts spaces between sentences", "eine Stadt.Sie liegt an der Spree!Das", "eine Stadt. Sie liegt an der Spree! Das", nil),
+		Entry("works with umlauts", "über Österreich.Übrigens", "über Österreich. Übrigens", nil),
+		Entry("leaves abbreviations alone, but reports them", "der Verein e.V.Berlin", "der Verein e.V.Berlin", []string{"er Verein e.V.Berlin"}),
+		Entry("does not cut characters in reports", "Straße Nr.Über", "Straße Nr.Über", []string{"Straße Nr.Über"}),
+		Entry("leaves domains alone", "bei wikipedia.org erhältlich", "bei wikipedia.org erhältlich", nil),
+	)
+
+	DescribeTable("FixDoubleSpaces", expectFixed(mediawiki.FixDoubleSpaces),
+		Entry("leaves clean text alone", "Berlin ist eine Stadt.", "Berlin ist eine Stadt.", nil),
+		Entry("collapses spaces", "Berlin  ist   eine\t\tStadt.", "Berlin ist eine Stadt.", nil),
+		Entry("removes spaces at line ends", "Stadt. \n== Geschichte == \nMehr", "Stadt.\n== Geschichte ==\nMehr", nil),
+		Entry("removes spaces before punctuation", "Berlin , die Hauptstadt .", "Berlin, die Hauptstadt.", nil),
+		Entry("keeps newlines", "Stadt.\n\nMehr", "Stadt.\n\nMehr", nil),
+	)
+
+	Describe("TextQualityPipeline", func() {
+		It("fixes all problems and reports findings", func() {
+			findings := &findingsCollector{}
+			page := &mediawiki.Page{
+				Title:   "Berlin",
+				Extract: "Berlin ( ; {{Audio|Berlin.ogg}}) ist eine Stadt.[1]Sie liegt an der Spree [sic] .\n== Geschichte ==\nMehr",
+			}
+
+			result := mediawiki.NewTextQualityPipeline(findings, nil).Process(page)
+
+			Expect(result.Extract).To(Equal("Berlin ist eine Stadt. Sie liegt an der Spree [sic].\n== Geschichte ==\nMehr"))
+			Expect(result.Title).To(Equal("Berlin"))
+			Expect(page.Extract).To(HavePrefix("Berlin ( ; {{Audio"), "must not modify the original page")
+			Expect(findings.findings).To(ConsistOf("der Spree [sic] .\n== Gesc"))
+		})
+
+		It("still fixes the text when findings cannot be persisted", func() {
+			result := mediawiki.NewTextQualityPipeline(failingPersistence{}, nil).Process(&mediawiki.Page{Extract: "Er sagte [sic] es.[1]"})
+
+			Expect(result.Extract).To(Equal("Er sagte [sic] es."))
+		})
+	})
+})
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// EMF writes measurements in CloudWatch's Embedded Metric Format, one JSON document per line. When written to the
+// logs of a Lambda function, CloudWatch extracts them as metrics.
+type EMF struct {
+	namespace string
+	mutex     sync.Mutex
+	writer    io.Writer
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func NewEMF(writer io.Writer, namespace string) *EMF {
+	return &EMF{writer: writer, namespace: namespace}
+}
+
+func (m *EMF) Duration(name string, d time.Duration, dimensions Dimensions) {
+	m.write(name, float64(d)/float64(time.Millisecond), "Milliseconds", dimensions)
+}
+
+func (m *EMF) Count(name string, dimensions Dimensions) {
+	m.write(name, 1, "Count", dimensions)
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+func (m *EMF) write(name string, value float64, unit string, dimensions Dimensions) {
+	document := map[string]interface{}{
+		"_aws": emfMetadata{
+			Timestamp: m.now().UnixNano() / int64(time.Millisecond),
+			CloudWatchMetrics: []emfDirective{{
+				Namespace:  m.namespace,
+				Dimensions: [][]string{dimensions.keys()},
+				Metrics:    []emfMetric{{Name: name, Unit: unit}},
+			}},
+		},
+		name: value,
+	}
+	for key, value := range dimensions {
+		document[key] = value
+	}
+	buf, _ := json.Marshal(document) // cannot fail for these types
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.writer.Write(append(buf, '\n'))
+}
+
+func (m *EMF) now() time.Time {
+	if m.Now == nil {
+		return time.Now()
+	}
+	return m.Now()
+}
@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/metrics"
+)
+
+var _ = Describe("EMF", func() {
+	var (
+		buffer *bytes.Buffer
+		emf    *metrics.EMF
+	)
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		emf = metrics.NewEMF(buffer, "AlexaWikipedia")
+		emf.Now = func() time.Time { return time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC) }
+	})
+
+	It("writes durations in milliseconds with their dimensions", func() {
+		emf.Duration("request_duration", 1500*time.Microsecond, metrics.Dimensions{"intent": "DefineIntent", "locale": "de-DE"})
+
+		Expect(buffer.String()).To(MatchJSON(`{
+			"_aws": {
+				"Timestamp": 1577880000000,
+				"CloudWatchMetrics": [{
+					"Namespace": "AlexaWikipedia",
+					"Dimensions": [["intent", "locale"]],
+					"Metrics": [{"Name": "request_duration", "Unit": "Milliseconds"}]
+				}]
+			},
+			"intent": "DefineIntent",
+			"locale": "de-DE",
+			"request_duration": 1.5
+		}`))
+	})
+
+	It("writes one document per line", func() {
+		emf.Count("chopper_fallbacks", nil)
+		emf.Count("chopper_fallbacks", nil)
+
+		lines := bytes.Split(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), []byte("\n"))
+		Expect(lines).To(HaveLen(2))
+		Expect(string(lines[0])).To(MatchJSON(`{
+			"_aws": {
+				"Timestamp": 1577880000000,
+				"CloudWatchMetrics": [{
+					"Namespace": "AlexaWikipedia",
+					"Dimensions": [[]],
+					"Metrics": [{"Name": "chopper_fallbacks", "Unit": "Count"}]
+				}]
+			},
+			"chopper_fallbacks": 1
+		}`))
+	})
+})
@@ -0,0 +1,34 @@
+// Package metrics records what the skill does, e.g. how long it takes to answer requests, in a backend independent
+// way.
+package metrics
+
+import (
+	"sort"
+	"time"
+)
+
+// Dimensions qualify a measurement, e.g. with the intent or locale it was taken for.
+type Dimensions map[string]string
+
+type Metrics interface {
+	// Duration records how long something took.
+	Duration(name string, d time.Duration, dimensions Dimensions)
+	// Count counts one occurrence of something.
+	Count(name string, dimensions Dimensions)
+}
+
+// NoOp drops all measurements.
+type NoOp struct{}
+
+func (NoOp) Duration(string, time.Duration, Dimensions) {}
+
+func (NoOp) Count(string, Dimensions) {}
+
+func (d Dimensions) keys() []string {
+	keys := make([]string, 0, len(d))
+	for key := range d {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the histogram buckets for durations.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Prometheus keeps measurements in memory and serves them in Prometheus's text format. Counts become counters named
+// <name>_total, durations histograms named <name>_seconds.
+type Prometheus struct {
+	buckets    []float64
+	mutex      sync.Mutex
+	counters   map[string]map[string]float64
+	histograms map[string]map[string]*histogram
+}
+
+type histogram struct {
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+func NewPrometheus(buckets []float64) *Prometheus {
+	return &Prometheus{
+		buckets:    buckets,
+		counters:   make(map[string]map[string]float64),
+		histograms: make(map[string]map[string]*histogram),
+	}
+}
+
+func (m *Prometheus) Duration(name string, d time.Duration, dimensions Dimensions) {
+	name, labels := name+"_seconds", labelsFrom(dimensions)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.histograms[name] == nil {
+		m.histograms[name] = make(map[string]*histogram)
+	}
+	h := m.histograms[name][labels]
+	if h == nil {
+		h = &histogram{bucketCounts: make([]uint64, len(m.buckets))}
+		m.histograms[name][labels] = h
+	}
+	for i, upperBound := range m.buckets {
+		if d.Seconds() <= upperBound {
+			h.bucketCounts[i]++
+		}
+	}
+	h.sum += d.Seconds()
+	h.count++
+}
+
+func (m *Prometheus) Count(name string, dimensions Dimensions) {
+	name = name + "_total"
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.counters[name] == nil {
+		m.counters[name] = make(map[string]float64)
+	}
+	m.counters[name][labelsFrom(dimensions)]++
+}
+
+func (m *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	io.WriteString(w, m.Text())
+}
+
+// Text returns all measurements in Prometheus's text format, sorted by name and labels.
+func (m *Prometheus) Text() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var text strings.Builder
+	var names []string
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&text, "# TYPE %v counter\n", name)
+		var labelsList []string
+		for labels := range m.counters[name] {
+			labelsList = append(labelsList, labels)
+		}
+		sort.Strings(labelsList)
+		for _, labels := range labelsList {
+			fmt.Fprintf(&text, "%v%v %v\n", name, braced(labels), formatFloat(m.counters[name][labels]))
+		}
+	}
+	names = nil
+	for name := range m.histograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&text, "# TYPE %v histogram\n", name)
+		var labelsList []string
+		for labels := range m.histograms[name] {
+			labelsList = append(labelsList, labels)
+		}
+		sort.Strings(labelsList)
+		for _, labels := range labelsList {
+			h := m.histograms[name][labels]
+			for i, upperBound := range m.buckets {
+				fmt.Fprintf(&text, "%v_bucket%v %v\n", name, braced(withLabel(labels, "le", formatFloat(upperBound))), h.bucketCounts[i])
+			}
+			fmt.Fprintf(&text, "%v_bucket%v %v\n", name, braced(withLabel(labels, "le", "+Inf")), h.count)
+			fmt.Fprintf(&text, "%v_sum%v %v\n", name, braced(labels), formatFloat(h.sum))
+			fmt.Fprintf(&text, "%v_count%v %v\n", name, braced(labels), h.count)
+		}
+	}
+	return text.String()
+}
+
+// labelsFrom formats dimensions as Prometheus labels without the surrounding braces, e.g. `intent="DefineIntent"`.
+func labelsFrom(dimensions Dimensions) string {
+	var labels []string
+	for _, key := range dimensions.keys() {
+		labels = append(labels, label(key, dimensions[key]))
+	}
+	return strings.Join(labels, ",")
+}
+
+func label(key, value string) string {
+	return key + `="` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func withLabel(labels string, key, value string) string {
+	if labels == "" {
+		return label(key, value)
+	}
+	return labels + "," + label(key, value)
+}
+
+func braced(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
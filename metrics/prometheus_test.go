@@ -0,0 +1,64 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/metrics"
+)
+
+var _ = Describe("Prometheus", func() {
+	var prometheus *metrics.Prometheus
+
+	BeforeEach(func() {
+		prometheus = metrics.NewPrometheus([]float64{0.1, 1})
+	})
+
+	It("exposes counts as counters", func() {
+		prometheus.Count("definition_lookups", metrics.Dimensions{"source": "get_page", "locale": "de-DE"})
+		prometheus.Count("definition_lookups", metrics.Dimensions{"source": "get_page", "locale": "de-DE"})
+		prometheus.Count("definition_lookups", metrics.Dimensions{"source": "not_found", "locale": "de-DE"})
+		prometheus.Count("chopper_fallbacks", nil)
+
+		Expect(prometheus.Text()).To(Equal(`# TYPE chopper_fallbacks_total counter
+chopper_fallbacks_total 1
+# TYPE definition_lookups_total counter
+definition_lookups_total{locale="de-DE",source="get_page"} 2
+definition_lookups_total{locale="de-DE",source="not_found"} 1
+`))
+	})
+
+	It("exposes durations as histograms in seconds", func() {
+		prometheus.Duration("request_duration", 50*time.Millisecond, metrics.Dimensions{"intent": "DefineIntent"})
+		prometheus.Duration("request_duration", 500*time.Millisecond, metrics.Dimensions{"intent": "DefineIntent"})
+		prometheus.Duration("request_duration", 2*time.Second, metrics.Dimensions{"intent": "DefineIntent"})
+
+		Expect(prometheus.Text()).To(Equal(`# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{intent="DefineIntent",le="0.1"} 1
+request_duration_seconds_bucket{intent="DefineIntent",le="1"} 2
+request_duration_seconds_bucket{intent="DefineIntent",le="+Inf"} 3
+request_duration_seconds_sum{intent="DefineIntent"} 2.55
+request_duration_seconds_count{intent="DefineIntent"} 3
+`))
+	})
+
+	It("escapes label values", func() {
+		prometheus.Count("definition_lookups", metrics.Dimensions{"locale": "a\"b\\c\nd"})
+
+		Expect(prometheus.Text()).To(ContainSubstring(`definition_lookups_total{locale="a\"b\\c\nd"} 1`))
+	})
+
+	It("serves the text format over HTTP", func() {
+		prometheus.Count("chopper_fallbacks", nil)
+		recorder := httptest.NewRecorder()
+
+		prometheus.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4"))
+		Expect(recorder.Body.String()).To(Equal(prometheus.Text()))
+	})
+})
@@ -0,0 +1,56 @@
+package preferences
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/pkg/errors"
+)
+
+type DynamoDBStore struct {
+	dynamo    *dynamodb.DynamoDB
+	tableName string
+}
+
+func NewDynamoDBStore(dynamoClient *dynamodb.DynamoDB, tableName string) *DynamoDBStore {
+	return &DynamoDBStore{
+		dynamo:    dynamoClient,
+		tableName: tableName,
+	}
+}
+
+type item struct {
+	UserID string `dynamodbav:"UserID"`
+	Preferences
+}
+
+func (s *DynamoDBStore) Get(userID string) (Preferences, error) {
+	output, e := s.dynamo.GetItem(&dynamodb.GetItemInput{
+		Key:       map[string]*dynamodb.AttributeValue{"UserID": {S: aws.String(userID)}},
+		TableName: &s.tableName,
+	})
+	if e != nil {
+		return Preferences{}, errors.Wrap(e, "Could not get preferences")
+	}
+	var i item
+	e = dynamodbattribute.UnmarshalMap(output.Item, &i)
+	if e != nil {
+		return Preferences{}, errors.Wrap(e, "Could not unmarshal preferences")
+	}
+	return i.Preferences, nil
+}
+
+func (s *DynamoDBStore) Put(userID string, preferences Preferences) error {
+	input, e := dynamodbattribute.MarshalMap(item{UserID: userID, Preferences: preferences})
+	if e != nil {
+		return errors.Wrap(e, "Could not marshal preferences")
+	}
+	_, e = s.dynamo.PutItem(&dynamodb.PutItemInput{
+		Item:      input,
+		TableName: &s.tableName,
+	})
+	if e != nil {
+		return errors.Wrap(e, "Could not put preferences")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package preferences
+
+import "sync"
+
+const (
+	ReadingModeFull    = ""
+	ReadingModeSummary = "summary"
+)
+
+type Preferences struct {
+	ReadingMode string `dynamodbav:"ReadingMode" json:"reading_mode"`
+	// MaxBodyPartLen is the maximum length of text read before asking whether to continue. 0 means default length.
+	MaxBodyPartLen int `dynamodbav:"MaxBodyPartLen" json:"max_body_part_len"`
+	// Bookmark is where the user left off when the last session timed out. Nil if there is nothing to resume.
+	Bookmark *Bookmark `dynamodbav:"Bookmark,omitempty" json:"bookmark,omitempty"`
+}
+
+type Bookmark struct {
+	Word                      string `dynamodbav:"Word" json:"word"`
+	Position                  int    `dynamodbav:"Position" json:"position"`
+	PositionWithinSectionBody int    `dynamodbav:"PositionWithinSectionBody" json:"position_within_section_body"`
+	ReadingMode               string `dynamodbav:"ReadingMode" json:"reading_mode"`
+}
+
+type Store interface {
+	Get(userID string) (Preferences, error)
+	Put(userID string, preferences Preferences) error
+}
+
+// InMemoryStore keeps preferences only for the lifetime of the process. It's meant for local use and tests.
+type InMemoryStore struct {
+	mutex       sync.Mutex
+	preferences map[string]Preferences
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{preferences: make(map[string]Preferences)}
+}
+
+func (s *InMemoryStore) Get(userID string) (Preferences, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.preferences[userID], nil
+}
+
+func (s *InMemoryStore) Put(userID string, preferences Preferences) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.preferences[userID] = preferences
+	return nil
+}
@@ -0,0 +1,71 @@
+package recording_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/recording"
+)
+
+func get(client *http.Client, url string) (string, error) {
+	response, e := client.Get(url)
+	if e != nil {
+		return "", e
+	}
+	defer response.Body.Close()
+	body, e := io.ReadAll(response.Body)
+	return string(body), e
+}
+
+var _ = Describe("Recording", func() {
+	var (
+		dir      string
+		upstream *httptest.Server
+		requests int
+	)
+
+	BeforeEach(func() {
+		var e error
+		dir, e = os.MkdirTemp("", "recording")
+		Expect(e).NotTo(HaveOccurred())
+		requests = 0
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"query":"` + r.URL.Query().Get("q") + `"}`))
+		}))
+	})
+
+	AfterEach(func() {
+		upstream.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("replays what was recorded without contacting upstream", func() {
+		path := filepath.Join(dir, "cassettes", "baum.json")
+		recorder := &http.Client{Transport: &recording.Recorder{Path: path}}
+		Expect(get(recorder, upstream.URL+"?q=baum")).To(Equal(`{"query":"baum"}`))
+		get(recorder, upstream.URL+"?q=haus")
+		Expect(requests).To(Equal(2))
+
+		replayer := &http.Client{Transport: &recording.Replayer{Path: path}}
+		Expect(get(replayer, upstream.URL+"?q=haus")).To(Equal(`{"query":"haus"}`))
+		Expect(get(replayer, upstream.URL+"?q=baum")).To(Equal(`{"query":"baum"}`))
+		Expect(requests).To(Equal(2))
+	})
+
+	It("fails for requests that were not recorded", func() {
+		replayer := &http.Client{Transport: &recording.Replayer{Path: filepath.Join(dir, "missing.json")}}
+
+		_, e := get(replayer, upstream.URL+"?q=baum")
+
+		Expect(e).To(MatchError(ContainSubstring("no recorded response for GET")))
+		Expect(requests).To(BeZero())
+	})
+})
@@ -0,0 +1,157 @@
+// Package redaction removes personal data from what the skill sends out of the process, like interaction logs and
+// error reports.
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRotationPeriod is how long a salt is used for hashing IDs.
+	DefaultRotationPeriod = 24 * time.Hour
+	// MaxLookbackPeriods limits how many past salts HashesSince considers.
+	MaxLookbackPeriods = 30
+
+	// Redacted replaces credentials.
+	Redacted = "<REDACTED>"
+	// Email replaces email addresses in free text.
+	Email = "<EMAIL>"
+	// Phone replaces phone numbers in free text.
+	Phone = "<PHONE>"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// phoneCandidatePattern also matches other numbers. phoneNumber sorts those out.
+	phoneCandidatePattern = regexp.MustCompile(`(?:\+|\(|\b)\d[\d \-./()]{5,}\d\b`)
+	yearRangePattern      = regexp.MustCompile(`^\d{4} ?[\-/] ?\d{4}$`)
+
+	idKeys         = map[string]bool{"userid": true, "user_id": true, "sessionid": true, "session_id": true, "deviceid": true, "personid": true}
+	credentialKeys = map[string]bool{"accesstoken": true, "apiaccesstoken": true, "consenttoken": true}
+)
+
+// Redactor hashes IDs, so the interactions of a user can still be correlated, but not traced back to the Alexa user.
+// The hash is keyed with a salt derived from secret and the RotationPeriod the hash is taken in. So hashes of the same
+// ID only match within one period, and without the secret they cannot be reproduced at all.
+type Redactor struct {
+	secret         []byte
+	RotationPeriod time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func NewRedactor(secret []byte) *Redactor {
+	return &Redactor{secret: secret, RotationPeriod: DefaultRotationPeriod}
+}
+
+// Hash hashes id with the current salt.
+func (r *Redactor) Hash(id string) string {
+	return r.hashInPeriod(id, r.period(r.now()))
+}
+
+// HashesSince returns the hashes id had since t, the current one first. Use it to look up data stored under
+// hashed IDs.
+func (r *Redactor) HashesSince(id string, t time.Time) []string {
+	current := r.period(r.now())
+	oldest := r.period(t)
+	if current-oldest >= MaxLookbackPeriods {
+		oldest = current - MaxLookbackPeriods + 1
+	}
+	var hashes []string
+	for period := current; period >= oldest; period-- {
+		hashes = append(hashes, r.hashInPeriod(id, period))
+	}
+	return hashes
+}
+
+func (r *Redactor) hashInPeriod(id string, period int64) string {
+	if id == "" {
+		return ""
+	}
+	salt := hmac.New(sha256.New, r.secret)
+	salt.Write([]byte(strconv.FormatInt(period, 10)))
+	hash := hmac.New(sha256.New, salt.Sum(nil))
+	hash.Write([]byte(id))
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
+func (r *Redactor) period(t time.Time) int64 {
+	return t.UnixNano() / int64(r.RotationPeriod)
+}
+
+func (r *Redactor) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+	return r.Now()
+}
+
+// Text replaces email addresses and phone numbers in text.
+func (r *Redactor) Text(text string) string {
+	text = emailPattern.ReplaceAllString(text, Email)
+	return phoneCandidatePattern.ReplaceAllStringFunc(text, func(candidate string) string {
+		if phoneNumber(candidate) {
+			return Phone
+		}
+		return candidate
+	})
+}
+
+func phoneNumber(candidate string) bool {
+	digits := 0
+	for _, c := range candidate {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15 && !yearRangePattern.MatchString(strings.TrimSpace(candidate))
+}
+
+// Value returns a redacted copy of v as it would be marshalled to JSON: IDs are hashed, credentials replaced and all
+// other strings redacted as Text. IDs and credentials are recognized by their keys, e.g. "userId" or "accessToken".
+func (r *Redactor) Value(v interface{}) interface{} {
+	buf, e := json.Marshal(v)
+	if e != nil {
+		return Redacted
+	}
+	var generic interface{}
+	if e = json.Unmarshal(buf, &generic); e != nil {
+		return Redacted
+	}
+	return r.redactValue("", generic)
+}
+
+func (r *Redactor) redactValue(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = r.redactValue(k, value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.redactValue(key, value)
+		}
+		return v
+	case string:
+		switch {
+		case v == "" || v == Redacted:
+			return v
+		case credentialKeys[strings.ToLower(key)]:
+			return Redacted
+		case idKeys[strings.ToLower(key)]:
+			return r.Hash(v)
+		default:
+			return r.Text(v)
+		}
+	default:
+		return v
+	}
+}
@@ -0,0 +1,101 @@
+package redaction_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/redaction"
+)
+
+var _ = Describe("Redactor", func() {
+	var (
+		now      time.Time
+		redactor *redaction.Redactor
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		redactor = redaction.NewRedactor([]byte("secret"))
+		redactor.Now = func() time.Time { return now }
+	})
+
+	Describe("Hash", func() {
+		It("hashes the same ID the same way within a rotation period", func() {
+			hash := redactor.Hash("some-user")
+			now = now.Add(11 * time.Hour)
+
+			Expect(hash).To(MatchRegexp(`^[0-9a-f]{32}$`))
+			Expect(redactor.Hash("some-user")).To(Equal(hash))
+			Expect(redactor.Hash("other-user")).NotTo(Equal(hash))
+		})
+
+		It("uses a new salt in the next rotation period", func() {
+			hash := redactor.Hash("some-user")
+			now = now.Add(12 * time.Hour)
+
+			Expect(redactor.Hash("some-user")).NotTo(Equal(hash))
+		})
+
+		It("depends on the secret", func() {
+			other := redaction.NewRedactor([]byte("other secret"))
+			other.Now = redactor.Now
+
+			Expect(other.Hash("some-user")).NotTo(Equal(redactor.Hash("some-user")))
+		})
+
+		It("keeps empty IDs empty", func() {
+			Expect(redactor.Hash("")).To(BeEmpty())
+		})
+	})
+
+	Describe("HashesSince", func() {
+		It("returns the hashes of all rotation periods since then, the current one first", func() {
+			yesterday := redactor.Hash("some-user")
+			now = now.Add(24 * time.Hour)
+
+			Expect(redactor.HashesSince("some-user", now.Add(-time.Second))).To(Equal([]string{redactor.Hash("some-user")}))
+			Expect(redactor.HashesSince("some-user", now.Add(-24*time.Hour))).To(Equal([]string{redactor.Hash("some-user"), yesterday}))
+		})
+
+		It("looks back at most MaxLookbackPeriods", func() {
+			Expect(redactor.HashesSince("some-user", time.Time{})).To(HaveLen(redaction.MaxLookbackPeriods))
+		})
+	})
+
+	DescribeTable("Text",
+		func(text string, expected string) {
+			Expect(redactor.Text(text)).To(Equal(expected))
+		},
+		Entry("email", "schreib an jane.doe@example.com bitte", "schreib an <EMAIL> bitte"),
+		Entry("international phone number", "ruf +49 171 1234567 an", "ruf <PHONE> an"),
+		Entry("phone number with separators", "call 555-123-4567", "call <PHONE>"),
+		Entry("phone number with area code in parentheses", "call (555) 123-4567", "call <PHONE>"),
+		Entry("year", "1984", "1984"),
+		Entry("year range", "Zweiter Weltkrieg 1939-1945", "Zweiter Weltkrieg 1939-1945"),
+		Entry("short number", "Route 66", "Route 66"),
+		Entry("hexadecimal number", "0x1dd6f806000", "0x1dd6f806000"),
+		Entry("plain text", "Käsekuchen", "Käsekuchen"),
+	)
+
+	It("redacts values by their keys", func() {
+		Expect(redactor.Value(map[string]interface{}{
+			"session": map[string]interface{}{
+				"sessionId":  "some-session",
+				"user":       map[string]string{"userId": "some-user", "accessToken": "some-token"},
+				"attributes": map[string]interface{}{"word": "jane@example.com", "position": 3},
+			},
+			"context": []interface{}{map[string]string{"deviceId": "some-device", "apiAccessToken": "some-api-token"}},
+		})).To(Equal(map[string]interface{}{
+			"session": map[string]interface{}{
+				"sessionId":  redactor.Hash("some-session"),
+				"user":       map[string]interface{}{"userId": redactor.Hash("some-user"), "accessToken": "<REDACTED>"},
+				"attributes": map[string]interface{}{"word": "<EMAIL>", "position": float64(3)},
+			},
+			"context": []interface{}{map[string]interface{}{"deviceId": redactor.Hash("some-device"), "apiAccessToken": "<REDACTED>"}},
+		}))
+		Expect(redactor.Value(nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,20 @@
+#!/bin/bash -ex
+
+# Creates the table the skill stores user preferences in. It lives next to the interactions table, which all
+# regions of the Lambda function share.
+
+region='eu-central-1'
+table='AlexaWikipediaUserPreferences'
+
+if aws --region $region dynamodb describe-table --table-name $table > /dev/null 2>&1; then
+    echo "Table $table already exists"
+    exit 0
+fi
+
+aws --region $region dynamodb create-table \
+  --table-name $table \
+  --attribute-definitions AttributeName=UserID,AttributeType=S \
+  --key-schema AttributeName=UserID,KeyType=HASH \
+  --billing-mode PAY_PER_REQUEST
+
+aws --region $region dynamodb wait table-exists --table-name $table
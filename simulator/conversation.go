@@ -0,0 +1,82 @@
+// Package simulator simulates conversations with a skill without a device, by resolving typed utterances using the
+// skill's interaction models and carrying over session attributes like Alexa does.
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/petergtz/go-alexa"
+)
+
+// Conversation sends requests to a skill as a single user, starting a new session whenever the previous one ended.
+type Conversation struct {
+	Skill    alexa.Skill
+	Resolver *Resolver
+	Locale   string
+	UserID   string
+
+	session      *alexa.Session
+	sessionCount int
+	requestCount int
+}
+
+// Say sends utterance to the skill: as LaunchRequest if it opens the skill, as IntentRequest otherwise.
+func (c *Conversation) Say(utterance string) *alexa.ResponseEnvelope {
+	if c.Resolver.IsLaunch(utterance) {
+		return c.Launch()
+	}
+	intent, _ := c.Resolver.Resolve(utterance)
+	return c.Send(&alexa.Request{Type: "IntentRequest", Intent: intent})
+}
+
+// Launch ends the current session, if any, and opens the skill in a new one.
+func (c *Conversation) Launch() *alexa.ResponseEnvelope {
+	if c.session != nil {
+		c.End("USER_INITIATED")
+	}
+	return c.Send(&alexa.Request{Type: "LaunchRequest"})
+}
+
+// End sends a SessionEndedRequest with reason, e.g. "EXCEEDED_MAX_REPROMPTS", if a session is open.
+func (c *Conversation) End(reason string) *alexa.ResponseEnvelope {
+	if c.session == nil {
+		return nil
+	}
+	response := c.Send(&alexa.Request{Type: "SessionEndedRequest", Reason: reason})
+	c.session = nil
+	return response
+}
+
+// InSession tells whether the skill kept the session open after the last request.
+func (c *Conversation) InSession() bool { return c.session != nil }
+
+// Send fills in request IDs, timestamp and locale of request and sends it within the current session, starting a new
+// one if necessary.
+func (c *Conversation) Send(request *alexa.Request) *alexa.ResponseEnvelope {
+	if c.session == nil {
+		c.sessionCount++
+		c.session = &alexa.Session{
+			New:       true,
+			SessionID: fmt.Sprintf("simulated-session-%v", c.sessionCount),
+			User:      alexa.User{UserID: c.UserID},
+		}
+	}
+	c.requestCount++
+	request.RequestID = fmt.Sprintf("simulated-request-%v", c.requestCount)
+	request.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	request.Locale = c.Locale
+
+	response := c.Skill.ProcessRequest(&alexa.RequestEnvelope{Version: "1.0", Session: c.session, Request: request})
+
+	if request.Type == "SessionEndedRequest" || response == nil || response.Response == nil || response.Response.ShouldSessionEnd {
+		c.session = nil
+		return response
+	}
+	c.session = &alexa.Session{
+		SessionID:  c.session.SessionID,
+		User:       c.session.User,
+		Attributes: response.SessionAttributes,
+	}
+	return response
+}
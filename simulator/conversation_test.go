@@ -0,0 +1,70 @@
+package simulator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/simulator"
+	"github.com/petergtz/go-alexa"
+)
+
+type countingSkill struct {
+	requests []*alexa.RequestEnvelope
+	endAfter int
+}
+
+func (s *countingSkill) ProcessRequest(requestEnv *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
+	s.requests = append(s.requests, requestEnv)
+	count, _ := requestEnv.Session.Attributes["count"].(int)
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response:          &alexa.Response{ShouldSessionEnd: len(s.requests) == s.endAfter},
+		SessionAttributes: map[string]interface{}{"count": count + 1},
+	}
+}
+
+var _ = Describe("Conversation", func() {
+	var (
+		skill        *countingSkill
+		conversation *simulator.Conversation
+	)
+
+	BeforeEach(func() {
+		model, e := simulator.LoadInteractionModel("../models", "de-DE")
+		Expect(e).NotTo(HaveOccurred())
+		skill = &countingSkill{endAfter: 3}
+		conversation = &simulator.Conversation{Skill: skill, Resolver: simulator.NewResolver(model, "de-DE"), Locale: "de-DE", UserID: "simulated-user"}
+	})
+
+	It("carries over session attributes until the skill ends the session", func() {
+		conversation.Say("öffne meine enzyklopädie")
+		conversation.Say("definiere Baum")
+		Expect(conversation.InSession()).To(BeTrue())
+		conversation.Say("stopp")
+		Expect(conversation.InSession()).To(BeFalse())
+		conversation.Say("hilfe")
+
+		Expect(skill.requests).To(HaveLen(4))
+		Expect(skill.requests[0].Request.Type).To(Equal("LaunchRequest"))
+		Expect(skill.requests[0].Session.New).To(BeTrue())
+		Expect(skill.requests[1].Request.Intent.Name).To(Equal("DefineIntent"))
+		Expect(skill.requests[1].Request.Locale).To(Equal("de-DE"))
+		Expect(skill.requests[1].Session.New).To(BeFalse())
+		Expect(skill.requests[1].Session.SessionID).To(Equal(skill.requests[0].Session.SessionID))
+		Expect(skill.requests[2].Session.Attributes).To(HaveKeyWithValue("count", 2))
+		Expect(skill.requests[3].Session.New).To(BeTrue())
+		Expect(skill.requests[3].Session.SessionID).NotTo(Equal(skill.requests[0].Session.SessionID))
+		Expect(skill.requests[3].Session.Attributes).To(BeEmpty())
+	})
+
+	It("sends SessionEndedRequests only for open sessions", func() {
+		Expect(conversation.End("EXCEEDED_MAX_REPROMPTS")).To(BeNil())
+
+		conversation.Launch()
+		conversation.End("EXCEEDED_MAX_REPROMPTS")
+
+		Expect(skill.requests).To(HaveLen(2))
+		Expect(skill.requests[1].Request.Type).To(Equal("SessionEndedRequest"))
+		Expect(skill.requests[1].Request.Reason).To(Equal("EXCEEDED_MAX_REPROMPTS"))
+		Expect(conversation.InSession()).To(BeFalse())
+	})
+})
@@ -0,0 +1,189 @@
+package simulator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/petergtz/go-alexa"
+)
+
+// InteractionModel is the part of a models/<locale>.json file needed to resolve utterances.
+type InteractionModel struct {
+	InteractionModel struct {
+		LanguageModel struct {
+			InvocationName string `json:"invocationName"`
+			Intents        []struct {
+				Name    string   `json:"name"`
+				Samples []string `json:"samples"`
+			} `json:"intents"`
+		} `json:"languageModel"`
+	} `json:"interactionModel"`
+}
+
+// LoadInteractionModel loads the interaction model for locale from modelsDir.
+func LoadInteractionModel(modelsDir string, locale string) (*InteractionModel, error) {
+	buf, e := os.ReadFile(filepath.Join(modelsDir, locale+".json"))
+	if e != nil {
+		return nil, e
+	}
+	var model InteractionModel
+	e = json.Unmarshal(buf, &model)
+	if e != nil {
+		return nil, e
+	}
+	return &model, nil
+}
+
+// builtInSamples stand in for the utterances Alexa knows for built-in intents, which don't need samples in models.
+var builtInSamples = map[string]map[string][]string{
+	"de": {
+		"AMAZON.YesIntent":          {"ja", "ja bitte", "gerne", "okay"},
+		"AMAZON.NoIntent":           {"nein", "nein danke"},
+		"AMAZON.HelpIntent":         {"hilfe", "was kann ich sagen"},
+		"AMAZON.StopIntent":         {"stopp", "stop", "halt", "aufhören"},
+		"AMAZON.CancelIntent":       {"abbrechen"},
+		"AMAZON.PauseIntent":        {"pause", "pausieren"},
+		"AMAZON.ResumeIntent":       {"weiter", "fortsetzen", "weiterlesen"},
+		"AMAZON.NextIntent":         {"nächster", "nächstes"},
+		"AMAZON.PreviousIntent":     {"zurück", "vorheriger"},
+		"AMAZON.RepeatIntent":       {"wiederholen", "wiederhole", "nochmal"},
+		"AMAZON.StartOverIntent":    {"von vorne"},
+		"AMAZON.NavigateHomeIntent": {"startseite", "nach hause"},
+	},
+	"en": {
+		"AMAZON.YesIntent":          {"yes", "yes please", "sure", "okay"},
+		"AMAZON.NoIntent":           {"no", "no thanks"},
+		"AMAZON.HelpIntent":         {"help", "what can i say"},
+		"AMAZON.StopIntent":         {"stop", "shut up", "off"},
+		"AMAZON.CancelIntent":       {"cancel", "never mind"},
+		"AMAZON.PauseIntent":        {"pause"},
+		"AMAZON.ResumeIntent":       {"resume", "continue", "go on"},
+		"AMAZON.NextIntent":         {"next"},
+		"AMAZON.PreviousIntent":     {"previous", "go back", "back"},
+		"AMAZON.RepeatIntent":       {"repeat", "say that again"},
+		"AMAZON.StartOverIntent":    {"start over", "restart"},
+		"AMAZON.NavigateHomeIntent": {"home", "go home"},
+	},
+	"es": {
+		"AMAZON.YesIntent":          {"sí", "si", "vale", "claro"},
+		"AMAZON.NoIntent":           {"no", "no gracias"},
+		"AMAZON.HelpIntent":         {"ayuda", "qué puedo decir"},
+		"AMAZON.StopIntent":         {"para", "detente", "stop"},
+		"AMAZON.CancelIntent":       {"cancela", "cancelar"},
+		"AMAZON.PauseIntent":        {"pausa"},
+		"AMAZON.ResumeIntent":       {"continúa", "sigue", "reanudar"},
+		"AMAZON.NextIntent":         {"siguiente"},
+		"AMAZON.PreviousIntent":     {"anterior", "atrás"},
+		"AMAZON.RepeatIntent":       {"repite", "repetir"},
+		"AMAZON.StartOverIntent":    {"empieza de nuevo", "desde el principio"},
+		"AMAZON.NavigateHomeIntent": {"inicio", "ir al inicio"},
+	},
+}
+
+var launchPhrases = map[string][]string{
+	"de": {"öffne", "starte"},
+	"en": {"open", "start", "launch"},
+	"es": {"abre", "inicia"},
+}
+
+type sample struct {
+	intentName   string
+	pattern      *regexp.Regexp
+	slotNames    []string
+	literalChars int
+}
+
+var slotPlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+func compileSample(intentName string, utterance string) sample {
+	s := sample{intentName: intentName}
+	var parts []string
+	for _, word := range strings.Split(normalize(utterance), " ") {
+		if match := slotPlaceholder.FindStringSubmatch(word); match != nil {
+			s.slotNames = append(s.slotNames, match[1])
+			parts = append(parts, `(.+?)`)
+			continue
+		}
+		s.literalChars += len(word)
+		parts = append(parts, regexp.QuoteMeta(word))
+	}
+	s.pattern = regexp.MustCompile("^" + strings.Join(parts, " ") + "$")
+	return s
+}
+
+// Resolver resolves typed utterances to intents, similar to how Alexa resolves spoken ones.
+type Resolver struct {
+	invocationName string
+	launchPhrases  []string
+	samples        []sample
+}
+
+// NewResolver creates a Resolver from the samples in model plus some common utterances for built-in intents.
+func NewResolver(model *InteractionModel, locale string) *Resolver {
+	language := strings.SplitN(locale, "-", 2)[0]
+	r := &Resolver{
+		invocationName: normalize(model.InteractionModel.LanguageModel.InvocationName),
+		launchPhrases:  launchPhrases[language],
+	}
+	for _, intent := range model.InteractionModel.LanguageModel.Intents {
+		for _, utterance := range intent.Samples {
+			r.samples = append(r.samples, compileSample(intent.Name, utterance))
+		}
+		for _, utterance := range builtInSamples[language][intent.Name] {
+			r.samples = append(r.samples, compileSample(intent.Name, utterance))
+		}
+	}
+	// Prefer samples that match literally. Among samples with slots, prefer those with more literal text,
+	// so e.g. "geh zu abschnitt {x}" wins over "geh zu {x}".
+	sort.SliceStable(r.samples, func(i, j int) bool {
+		if (len(r.samples[i].slotNames) == 0) != (len(r.samples[j].slotNames) == 0) {
+			return len(r.samples[i].slotNames) == 0
+		}
+		return r.samples[i].literalChars > r.samples[j].literalChars
+	})
+	return r
+}
+
+// IsLaunch tells whether utterance opens the skill, e.g. "öffne meine enzyklopädie".
+func (r *Resolver) IsLaunch(utterance string) bool {
+	utterance = normalize(utterance)
+	for _, phrase := range r.launchPhrases {
+		if utterance == phrase+" "+r.invocationName {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the intent with filled slots for utterance. If no sample matches, it returns
+// AMAZON.FallbackIntent and false.
+func (r *Resolver) Resolve(utterance string) (alexa.Intent, bool) {
+	utterance = normalize(utterance)
+	for _, s := range r.samples {
+		match := s.pattern.FindStringSubmatch(utterance)
+		if match == nil {
+			continue
+		}
+		intent := alexa.Intent{Name: s.intentName}
+		if len(s.slotNames) > 0 {
+			intent.Slots = make(map[string]alexa.IntentSlot)
+			for i, slotName := range s.slotNames {
+				intent.Slots[slotName] = alexa.IntentSlot{Name: slotName, Value: match[i+1]}
+			}
+		}
+		return intent, true
+	}
+	return alexa.Intent{Name: "AMAZON.FallbackIntent"}, false
+}
+
+// normalize lower-cases utterance, drops punctuation and collapses whitespace, like Alexa does when transcribing.
+func normalize(utterance string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(utterance), func(r rune) bool {
+		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '{' && r != '}' && r != '_' && r != '\'')
+	}), " ")
+}
@@ -0,0 +1,44 @@
+package simulator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/simulator"
+	"github.com/petergtz/go-alexa"
+)
+
+var _ = Describe("Resolver", func() {
+	var resolver *simulator.Resolver
+
+	BeforeEach(func() {
+		model, e := simulator.LoadInteractionModel("../models", "de-DE")
+		Expect(e).NotTo(HaveOccurred())
+		resolver = simulator.NewResolver(model, "de-DE")
+	})
+
+	DescribeTable("resolves utterances",
+		func(utterance string, expected alexa.Intent) {
+			intent, ok := resolver.Resolve(utterance)
+			Expect(ok).To(BeTrue())
+			Expect(intent).To(Equal(expected))
+		},
+		Entry("sample with slot", "Definiere Käsekuchen.", alexa.Intent{Name: "DefineIntent", Slots: map[string]alexa.IntentSlot{"word": {Name: "word", Value: "käsekuchen"}}}),
+		Entry("more specific sample", "geh zu Abschnitt 3", alexa.Intent{Name: "GoToSectionIntent", Slots: map[string]alexa.IntentSlot{"section_title_or_number": {Name: "section_title_or_number", Value: "3"}}}),
+		Entry("literal sample", "Inhaltsverzeichnis", alexa.Intent{Name: "TocIntent"}),
+		Entry("built-in intent", "Ja", alexa.Intent{Name: "AMAZON.YesIntent"}),
+		Entry("built-in intent in favor of slot", "weiter", alexa.Intent{Name: "AMAZON.ResumeIntent"}),
+	)
+
+	It("falls back for unknown utterances", func() {
+		intent, ok := resolver.Resolve("blubb")
+		Expect(ok).To(BeFalse())
+		Expect(intent.Name).To(Equal("AMAZON.FallbackIntent"))
+	})
+
+	It("recognizes the invocation", func() {
+		Expect(resolver.IsLaunch("Öffne meine Enzyklopädie")).To(BeTrue())
+		Expect(resolver.IsLaunch("Öffne Spotify")).To(BeFalse())
+	})
+})
@@ -0,0 +1,244 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/petergtz/alexa-wikipedia/apl"
+	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/mediawiki"
+	"github.com/petergtz/alexa-wikipedia/wiki"
+	"github.com/petergtz/go-alexa"
+)
+
+// Scenario is a conversation with the skill together with what the skill is expected to answer. Scenarios are
+// written in YAML or JSON, e.g.:
+//
+//	name: reads on when asked to
+//	locale: de-DE
+//	articles:
+//	  - title: Käsekuchen
+//	    extract: |
+//	      Käsekuchen ist ein Kuchen.
+//	      == Geschichte ==
+//	      Schon die Griechen kannten ihn.
+//	turns:
+//	  - user: öffne meine enzyklopädie
+//	  - user: definiere käsekuchen
+//	    expect:
+//	      speech_contains: [Käsekuchen ist ein Kuchen]
+//	      session: {position: 0, last_question: should_continue}
+//	  - user: ja
+//	    expect:
+//	      speech_contains: [Schon die Griechen]
+type Scenario struct {
+	Name   string `yaml:"name"`
+	Locale string `yaml:"locale"`
+	// Articles make up the wiki the skill searches during the scenario.
+	Articles []Article `yaml:"articles"`
+	Turns    []Turn    `yaml:"turns"`
+}
+
+// Article is a Wikipedia article in the format of the MediaWiki extracts API, i.e. with "== Title ==" headings.
+type Article struct {
+	Title   string `yaml:"title"`
+	Extract string `yaml:"extract"`
+}
+
+// Turn is one request to the skill. Exactly one of User, Intent, Event and SessionEnded must be set.
+type Turn struct {
+	// User is an utterance, resolved to a LaunchRequest or an intent like Alexa would.
+	User string `yaml:"user"`
+	// Intent is sent together with Slots as is, bypassing resolution.
+	Intent string            `yaml:"intent"`
+	Slots  map[string]string `yaml:"slots"`
+	// Event is the arguments of an APL UserEvent, e.g. a touch on the screen.
+	Event []string `yaml:"event"`
+	// SessionEnded is the reason of a SessionEndedRequest, e.g. "EXCEEDED_MAX_REPROMPTS".
+	SessionEnded string      `yaml:"session_ended"`
+	Expect       Expectation `yaml:"expect"`
+}
+
+// Expectation is checked against the skill's response. Unset fields are not checked.
+type Expectation struct {
+	Speech               string   `yaml:"speech"`
+	SpeechContains       []string `yaml:"speech_contains"`
+	SpeechDoesNotContain []string `yaml:"speech_does_not_contain"`
+	RepromptContains     []string `yaml:"reprompt_contains"`
+	// Session maps session attributes to their expected values. null means the attribute must not be set.
+	Session     map[string]interface{} `yaml:"session"`
+	SessionEnds *bool                  `yaml:"session_ends"`
+}
+
+// Result is what happened during a Turn.
+type Result struct {
+	Turn     Turn
+	Request  *alexa.RequestEnvelope
+	Response *alexa.ResponseEnvelope
+	Failures []string
+}
+
+func LoadScenario(path string) (*Scenario, error) {
+	buf, e := os.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	var scenario Scenario
+	decoder := yaml.NewDecoder(bytes.NewReader(buf))
+	decoder.KnownFields(true)
+	e = decoder.Decode(&scenario)
+	if e != nil {
+		return nil, fmt.Errorf("could not decode scenario %v: %w", path, e)
+	}
+	if scenario.Locale == "" {
+		return nil, fmt.Errorf("scenario %v has no locale", path)
+	}
+	return &scenario, nil
+}
+
+// Wiki returns a wiki.Wiki containing only the scenario's articles.
+func (s *Scenario) Wiki() wiki.Wiki { return articles(s.Articles) }
+
+// Run has the conversation of the scenario with skill, which should use the scenario's Wiki, and checks the
+// expectations of all turns. It stops at the first turn that's invalid.
+func (s *Scenario) Run(skill alexa.Skill, resolver *Resolver) []Result {
+	var lastRequest *alexa.RequestEnvelope
+	conversation := &Conversation{
+		Skill: skillFunc(func(requestEnv *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
+			lastRequest = requestEnv
+			return skill.ProcessRequest(requestEnv)
+		}),
+		Resolver: resolver,
+		Locale:   s.Locale,
+		UserID:   "scenario-user",
+	}
+	var results []Result
+	for _, turn := range s.Turns {
+		result := Result{Turn: turn}
+		lastRequest = nil
+		switch {
+		case turn.User != "":
+			result.Response = conversation.Say(turn.User)
+		case turn.Intent != "":
+			intent := alexa.Intent{Name: turn.Intent}
+			if len(turn.Slots) > 0 {
+				intent.Slots = make(map[string]alexa.IntentSlot)
+				for name, value := range turn.Slots {
+					intent.Slots[name] = alexa.IntentSlot{Name: name, Value: value}
+				}
+			}
+			result.Response = conversation.Send(&alexa.Request{Type: "IntentRequest", Intent: intent})
+		case len(turn.Event) > 0:
+			arguments, _ := json.Marshal(turn.Event)
+			result.Response = conversation.Send(&alexa.Request{Type: apl.UserEventType, Payload: arguments})
+		case turn.SessionEnded != "":
+			result.Response = conversation.End(turn.SessionEnded)
+		default:
+			result.Failures = []string{"turn has neither user, intent, event nor session_ended"}
+			return append(results, result)
+		}
+		result.Request = lastRequest
+		result.Failures = turn.Expect.check(result.Response)
+		results = append(results, result)
+	}
+	return results
+}
+
+type skillFunc func(*alexa.RequestEnvelope) *alexa.ResponseEnvelope
+
+func (f skillFunc) ProcessRequest(requestEnv *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
+	return f(requestEnv)
+}
+
+func (expect Expectation) check(response *alexa.ResponseEnvelope) []string {
+	var failures []string
+	var speech, reprompt string
+	var sessionEnds bool
+	var sessionAttributes map[string]interface{}
+	if response != nil && response.Response != nil {
+		if response.Response.OutputSpeech != nil {
+			speech = response.Response.OutputSpeech.Text + response.Response.OutputSpeech.SSML
+		}
+		if response.Response.Reprompt != nil && response.Response.Reprompt.OutputSpeech != nil {
+			reprompt = response.Response.Reprompt.OutputSpeech.Text + response.Response.Reprompt.OutputSpeech.SSML
+		}
+		sessionEnds = response.Response.ShouldSessionEnd
+		sessionAttributes = response.SessionAttributes
+	}
+
+	if expect.Speech != "" && speech != expect.Speech {
+		failures = append(failures, fmt.Sprintf("expected speech %q, but got %q", expect.Speech, speech))
+	}
+	for _, s := range expect.SpeechContains {
+		if !strings.Contains(speech, s) {
+			failures = append(failures, fmt.Sprintf("expected speech to contain %q, but got %q", s, speech))
+		}
+	}
+	for _, s := range expect.SpeechDoesNotContain {
+		if strings.Contains(speech, s) {
+			failures = append(failures, fmt.Sprintf("expected speech not to contain %q, but got %q", s, speech))
+		}
+	}
+	for _, s := range expect.RepromptContains {
+		if !strings.Contains(reprompt, s) {
+			failures = append(failures, fmt.Sprintf("expected reprompt to contain %q, but got %q", s, reprompt))
+		}
+	}
+	for name, expected := range expect.Session {
+		actual, found := sessionAttributes[name]
+		switch {
+		case expected == nil && found:
+			failures = append(failures, fmt.Sprintf("expected session attribute %v not to be set, but it is %v", name, actual))
+		case expected != nil && !found:
+			failures = append(failures, fmt.Sprintf("expected session attribute %v to be %v, but it is not set", name, expected))
+		case expected != nil && !sameJSON(expected, actual):
+			failures = append(failures, fmt.Sprintf("expected session attribute %v to be %v, but it is %v", name, expected, actual))
+		}
+	}
+	if expect.SessionEnds != nil && *expect.SessionEnds != sessionEnds {
+		failures = append(failures, fmt.Sprintf("expected session to end: %v, but it did: %v", *expect.SessionEnds, sessionEnds))
+	}
+	return failures
+}
+
+// sameJSON compares values the way they would arrive at the skill in the next request, so e.g. 2 and 2.0 are equal.
+func sameJSON(a interface{}, b interface{}) bool {
+	var normalizedA, normalizedB interface{}
+	bufA, eA := json.Marshal(a)
+	bufB, eB := json.Marshal(b)
+	if eA != nil || eB != nil || json.Unmarshal(bufA, &normalizedA) != nil || json.Unmarshal(bufB, &normalizedB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(normalizedA, normalizedB)
+}
+
+type articles []Article
+
+var errPageNotFound = errors.New("Page not found on Wikipedia")
+
+func (a articles) GetPage(ctx context.Context, word string, localizer *locale.Localizer) (wiki.Page, error) {
+	for _, article := range a {
+		if strings.EqualFold(article.Title, word) {
+			return mediawiki.WikiPageFrom(&mediawiki.Page{Title: article.Title, Extract: article.Extract}, localizer), nil
+		}
+	}
+	return wiki.Page{}, errPageNotFound
+}
+
+func (a articles) SearchPage(ctx context.Context, word string, localizer *locale.Localizer) (wiki.Page, error) {
+	for _, article := range a {
+		if strings.Contains(strings.ToLower(article.Title), strings.ToLower(word)) ||
+			strings.Contains(strings.ToLower(word), strings.ToLower(article.Title)) {
+			return mediawiki.WikiPageFrom(&mediawiki.Page{Title: article.Title, Extract: article.Extract}, localizer), nil
+		}
+	}
+	return wiki.Page{}, errPageNotFound
+}
@@ -0,0 +1,100 @@
+package simulator_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/simulator"
+	"github.com/petergtz/go-alexa"
+)
+
+type echoSkill struct{}
+
+func (echoSkill) ProcessRequest(requestEnv *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response:          &alexa.Response{OutputSpeech: &alexa.OutputSpeech{Type: "PlainText", Text: "You asked for " + requestEnv.Request.Intent.Name}},
+		SessionAttributes: map[string]interface{}{"position": 2, "word": "baum"},
+	}
+}
+
+var _ = Describe("Scenario", func() {
+	var resolver *simulator.Resolver
+
+	BeforeEach(func() {
+		model, e := simulator.LoadInteractionModel("../models", "de-DE")
+		Expect(e).NotTo(HaveOccurred())
+		resolver = simulator.NewResolver(model, "de-DE")
+	})
+
+	loadScenario := func(content string) *simulator.Scenario {
+		dir, e := os.MkdirTemp("", "scenario")
+		Expect(e).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		Expect(os.WriteFile(filepath.Join(dir, "scenario.yaml"), []byte(content), 0644)).To(Succeed())
+		scenario, e := simulator.LoadScenario(filepath.Join(dir, "scenario.yaml"))
+		Expect(e).NotTo(HaveOccurred())
+		return scenario
+	}
+
+	It("checks the expectations of every turn", func() {
+		results := loadScenario(`
+locale: de-DE
+turns:
+  - user: inhaltsverzeichnis
+    expect:
+      speech: You asked for TocIntent
+      session: {position: 2, last_question: null}
+      session_ends: false
+  - intent: WhereAmIIntent
+    expect:
+      speech_contains: [TocIntent]
+      session: {position: 3, word: null}
+`).Run(echoSkill{}, resolver)
+
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Failures).To(BeEmpty())
+		Expect(results[0].Request.Request.Intent.Name).To(Equal("TocIntent"))
+		Expect(results[1].Failures).To(ConsistOf(
+			`expected speech to contain "TocIntent", but got "You asked for WhereAmIIntent"`,
+			`expected session attribute position to be 3, but it is 2`,
+			`expected session attribute word not to be set, but it is baum`,
+		))
+	})
+
+	It("serves the scenario's articles", func() {
+		scenario := loadScenario(`
+locale: de-DE
+articles:
+  - title: Baum
+    extract: "Ein Baum ist eine Pflanze.\n== Aufbau ==\nWurzeln, Stamm und Krone.\n"
+`)
+
+		localizer := locale.NewLocalizer(i18n.NewBundle(language.German), "de-DE", zap.NewNop().Sugar())
+
+		page, e := scenario.Wiki().SearchPage(context.Background(), "bäume und baum", localizer)
+		Expect(e).NotTo(HaveOccurred())
+		Expect(page.Title).To(Equal("Baum"))
+		Expect(page.Subsections[0].Title).To(Equal("Aufbau"))
+		_, e = scenario.Wiki().GetPage(context.Background(), "Haus", localizer)
+		Expect(e).To(MatchError("Page not found on Wikipedia"))
+	})
+
+	It("rejects unknown fields", func() {
+		dir, e := os.MkdirTemp("", "scenario")
+		Expect(e).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		Expect(os.WriteFile(filepath.Join(dir, "scenario.yaml"), []byte("locale: de-DE\nturns:\n  - usr: ja\n"), 0644)).To(Succeed())
+
+		_, e = simulator.LoadScenario(filepath.Join(dir, "scenario.yaml"))
+
+		Expect(e).To(MatchError(ContainSubstring("field usr not found")))
+	})
+})
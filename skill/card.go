@@ -0,0 +1,40 @@
+package skill
+
+import (
+	"net/url"
+	"strings"
+
+	. "github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/wiki"
+	"github.com/petergtz/go-alexa"
+)
+
+// articleCard creates a card for the Alexa app, so users can find the article again later.
+// Wikipedia's content is licensed under CC BY-SA, which requires attribution.
+func articleCard(page wiki.Page, l *locale.Localizer) *alexa.Card {
+	text := firstParagraphOf(page.Body) + "\n\n" + l.MustLocalize(&LocalizeConfig{
+		DefaultMessage: &Message{
+			ID:    "CardAttribution",
+			Other: "Quelle: Wikipedia, {{.URL}}. Der Text ist unter der Lizenz CC BY-SA 4.0 verfügbar.",
+		},
+		TemplateData: map[string]string{"URL": articleURL(page.Title, l)},
+	})
+	if page.ImageURL == "" {
+		return &alexa.Card{Type: "Simple", Title: page.Title, Content: text}
+	}
+	return &alexa.Card{
+		Type:  "Standard",
+		Title: page.Title,
+		Text:  text,
+		Image: &alexa.Image{SmallImageURL: page.ImageURL, LargeImageURL: page.ImageURL},
+	}
+}
+
+func articleURL(title string, l *locale.Localizer) string {
+	return "https://" + l.WikiEndpoint() + "/wiki/" + url.PathEscape(strings.ReplaceAll(title, " ", "_"))
+}
+
+func firstParagraphOf(body string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(body), "\n", 2)[0])
+}
@@ -0,0 +1,219 @@
+package skill
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/tracing"
+	"github.com/petergtz/alexa-wikipedia/wiki"
+	"github.com/petergtz/go-alexa"
+)
+
+func (h *WikipediaSkill) define(r *Request) *alexa.ResponseEnvelope {
+	l, logger := r.Localizer, r.Logger
+	logger.Debugw("DefineIntent begin")
+
+	var (
+		userInteractions []*alexa.Interaction
+		userPreferences  preferences.Preferences
+		wg               sync.WaitGroup
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		logger.Debugw("Before GetInteractionsByUser")
+		startTime := time.Now()
+		_, span := tracing.Start(r.Context, "GetInteractionsByUser")
+		userInteractions = h.interactionHistory.GetInteractionsByUser(r.UserID(), time.Now().Add(-10*time.Second))
+		span.End()
+		logger.Debugw("After GetInteractionsByUser", "duration", time.Since(startTime).String())
+	}()
+	go func() {
+		defer wg.Done()
+		userPreferences = h.preferencesFor(r.UserID(), logger)
+	}()
+
+	startTime := time.Now()
+	definition, e := h.findDefinition(r.Context, r.Slot("word"), l)
+	logger.Debugw("findDefinition finished", "duration", time.Since(startTime).String())
+	if e != nil {
+		logger.Errorw("Could not get Wikipedia page", "error", e)
+		return internalError(l)
+	}
+	if definition == nil {
+		r.InteractionAttributes = map[string]interface{}{
+			"Intent":      r.Intent().Name,
+			"SearchQuery": r.Slot("word"),
+			"ActualTitle": "NOT_FOUND",
+		}
+
+		return &alexa.ResponseEnvelope{Version: "1.0",
+			Response: &alexa.Response{
+				OutputSpeech: plainText(
+					l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+						ID:    "CouldNotFindExpression",
+						Other: "Diesen Begriff konnte ich bei Wikipedia leider nicht finden. Versuche es doch mit einem anderen Begriff.",
+					}}),
+				),
+			},
+		}
+	}
+	wg.Wait()
+	if titleWasAlreadyRecentlyFound(definition.Title, userInteractions) {
+		logger.Debug("title was recently found", "title", definition.Title)
+		return h.articleResponse(r, *definition, r.Slot("word"), userPreferences,
+			l.MustLocalize(&LocalizeConfig{
+				DefaultMessage: &Message{
+					ID: "SpellingHint",
+					Other: "Ich habe den Artikel, \"{{.Title}}\", gerade erst gelesen. " +
+						"Falls ich nicht Deinen gewünschten Artikel gefunden habe, unterbrich mich und sage: " +
+						"\"Alexa, Suche buchstabieren\", um Deine Suchanfrage zu buchstabieren. Hier ist der Artikel:",
+				},
+				TemplateData: map[string]string{"Title": definition.Title},
+			}))
+	}
+	logger.Debugw("title was not recently found", "title", definition.Title)
+	r.InteractionAttributes = map[string]interface{}{
+		"Intent":      r.Intent().Name,
+		"SearchQuery": r.Slot("word"),
+		"ActualTitle": definition.Title,
+	}
+	return h.articleResponse(r, *definition, r.Slot("word"), userPreferences, "")
+}
+
+func (h *WikipediaSkill) spell(r *Request) *alexa.ResponseEnvelope {
+	l, logger := r.Localizer, r.Logger
+	couldNotFindSpelledTerm := &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(
+				l.MustLocalize(&LocalizeConfig{
+					DefaultMessage: &Message{
+						ID:    "CouldNotFindSpelledTerm",
+						Other: "Den buchstabierten Begriff {{.SpelledTerm}} konnte ich bei Wikipedia leider nicht finden. Versuche es doch mit einem anderen Begriff.",
+					},
+					TemplateData: map[string]string{"SpelledTerm": r.Slot("spelled_term")},
+				}),
+			),
+		},
+	}
+	if r.Slot("spelled_term") == "" {
+		// TODO: it's unclear why this ever happens, but according to logs this is what Alexa sometimes sends, especially during certification.
+		//       This could get better error handling or even some slot elicitation (which should have happened automatically), but for now
+		//       this is good enough.
+		return couldNotFindSpelledTerm
+	}
+	assembledSearchQuery := l.AssembleTermFromSpelling(r.Slot("spelled_term"))
+	userPreferences := h.preferencesFor(r.UserID(), logger)
+	definition, e := h.findDefinition(r.Context, assembledSearchQuery, l)
+	if e != nil {
+		logger.Errorw("Could not get Wikipedia page", "error", e)
+		return internalError(l)
+	}
+	actualTitle := "NOT_FOUND"
+	if definition != nil {
+		actualTitle = definition.Title
+	}
+	r.InteractionAttributes = map[string]interface{}{
+		"Intent":               r.Intent().Name,
+		"SpelledSearchQuery":   r.Slot("spelled_term"),
+		"AssembledSearchQuery": assembledSearchQuery,
+		"ActualTitle":          actualTitle,
+	}
+	if definition == nil {
+		return couldNotFindSpelledTerm
+	}
+	return h.articleResponse(r, *definition, assembledSearchQuery, userPreferences, "")
+}
+
+// articleResponse reads the beginning of a newly found article. An optional intro is spoken before it.
+func (h *WikipediaSkill) articleResponse(r *Request, page wiki.Page, word string, userPreferences preferences.Preferences, intro string) *alexa.ResponseEnvelope {
+	l := r.Localizer
+	text := strings.TrimRight(newBodyChopper(userPreferences.MaxBodyPartLen, h.countChopperFallback).FetchBodyPart(page.Body, 0), ". ") + ". " +
+		l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID: "FurtherNavigationHints",
+			Other: "Zur weiteren Navigation kannst Du jederzeit zum Inhaltsverzeichnis springen" +
+				" indem Du \"Inhaltsverzeichnis\" oder \"nächster Abschnitt\" sagst. " +
+				"Soll ich zunächst einfach weiterlesen?",
+		}})
+	if intro != "" {
+		text = intro + "\n\n" + text
+	}
+	return withArticleRendering(r.Envelope, page, &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			Card:         articleCard(page, l),
+			OutputSpeech: plainText(text),
+		},
+		SessionAttributes: SessionState{Word: word, LastQuestion: DialogStateShouldContinue}.
+			WithPreferences(userPreferences).Encode(),
+	}, l)
+}
+
+const recentlyFoundThreshold = 20 * time.Second
+
+func titleWasAlreadyRecentlyFound(currentTitle string, userInteractions []*alexa.Interaction) bool {
+	for i := len(userInteractions) - 1; i >= 0; i-- {
+		if userInteractions[i].RequestType == "IntentRequest" &&
+			userInteractions[i].Attributes["Intent"] == "DefineIntent" &&
+			userInteractions[i].Attributes["ActualTitle"] == currentTitle &&
+			time.Now().Sub(userInteractions[i].Timestamp) < recentlyFoundThreshold {
+
+			return true
+		}
+	}
+	return false
+}
+
+// findDefinition looks up the page titled word and searches for word at the same time. It prefers the former. Which
+// one won is counted per locale.
+func (h *WikipediaSkill) findDefinition(ctx context.Context, word string, l *locale.Localizer) (*wiki.Page, error) {
+	ctx, span := tracing.Start(ctx, "findDefinition")
+	defer span.End()
+	page, source, e := h.lookUpDefinition(ctx, word, l)
+	h.metrics.Count("definition_lookups", metrics.Dimensions{"locale": l.Lang(), "source": source})
+	span.SetAttribute("source", source)
+	span.RecordError(e)
+	return page, e
+}
+
+func (h *WikipediaSkill) lookUpDefinition(ctx context.Context, word string, l *locale.Localizer) (page *wiki.Page, source string, e error) {
+	var (
+		searchResult wiki.Page
+		searchError  error
+		wg           sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		// TODO: Is this really necessary? Potentially remove.
+		time.Sleep(10 * time.Millisecond) // Attempt to see if it helps when GetPage starts first
+		searchResult, searchError = h.wiki.SearchPage(ctx, word, l)
+		wg.Done()
+	}()
+	result, e := h.wiki.GetPage(ctx, word, l)
+	switch {
+	case isNotFoundError(e):
+		wg.Wait()
+		switch {
+		case isNotFoundError(searchError):
+			return nil, "not_found", nil
+		case searchError != nil:
+			return nil, "error", searchError
+		default:
+			return &searchResult, "search_page", nil
+		}
+	case e != nil:
+		return nil, "error", e
+	default:
+		return &result, "get_page", nil
+	}
+}
+
+func isNotFoundError(e error) bool {
+	return e != nil && e.Error() == "Page not found on Wikipedia"
+}
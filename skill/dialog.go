@@ -0,0 +1,162 @@
+package skill
+
+import (
+	. "github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/go-alexa"
+)
+
+// DialogState is the question the skill asked last, and therefore determines how answers like "yes" or "no" are
+// interpreted. It's kept in the session attribute "last_question".
+type DialogState string
+
+const (
+	DialogStateNone           DialogState = "none"
+	DialogStateShouldContinue DialogState = "should_continue"
+	DialogStateJumpWhere      DialogState = "jump_where"
+	DialogStateShouldResume   DialogState = "should_resume"
+)
+
+type dialogHandler func(h *WikipediaSkill, r *Request) *alexa.ResponseEnvelope
+
+type dialogStateDefinition struct {
+	onYes      dialogHandler
+	onNo       dialogHandler
+	onFallback dialogHandler
+	// transitions are the states the above handlers may lead to.
+	transitions []DialogState
+}
+
+var dialogStates = map[DialogState]dialogStateDefinition{
+	DialogStateNone: {
+		onYes:       (*WikipediaSkill).what,
+		onNo:        (*WikipediaSkill).what,
+		onFallback:  (*WikipediaSkill).fallback,
+		transitions: []DialogState{DialogStateNone},
+	},
+	DialogStateShouldContinue: {
+		onYes:       (*WikipediaSkill).continueReading,
+		onNo:        (*WikipediaSkill).stopReading,
+		onFallback:  (*WikipediaSkill).askToAnswerYesOrNo,
+		transitions: []DialogState{DialogStateShouldContinue, DialogStateNone},
+	},
+	DialogStateJumpWhere: {
+		onYes:       (*WikipediaSkill).askWhichSection,
+		onNo:        (*WikipediaSkill).offerToContinue,
+		onFallback:  (*WikipediaSkill).askWhichSection,
+		transitions: []DialogState{DialogStateJumpWhere, DialogStateShouldContinue},
+	},
+	DialogStateShouldResume: {
+		onYes:       (*WikipediaSkill).continueReading,
+		onNo:        (*WikipediaSkill).startAfresh,
+		onFallback:  (*WikipediaSkill).askToAnswerYesOrNo,
+		transitions: []DialogState{DialogStateShouldContinue, DialogStateNone, DialogStateShouldResume},
+	},
+}
+
+// DialogStates returns all declared dialog states.
+func DialogStates() []DialogState {
+	return []DialogState{DialogStateNone, DialogStateShouldContinue, DialogStateJumpWhere, DialogStateShouldResume}
+}
+
+// CanTransition tells whether answering in dialog state from may lead to dialog state to.
+func CanTransition(from DialogState, to DialogState) bool {
+	for _, state := range dialogStates[from].transitions {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// answer handles answers to the last question, e.g. "yes" or "no", according to the current dialog state.
+func (h *WikipediaSkill) answer(handlerFor func(dialogStateDefinition) dialogHandler) Handler {
+	return HandlerFunc(func(r *Request) *alexa.ResponseEnvelope {
+		from := r.State.LastQuestion
+		response := handlerFor(dialogStates[from])(h, r)
+		if to := DecodeSessionState(response.SessionAttributes).LastQuestion; !CanTransition(from, to) {
+			r.Logger.Errorw("Invalid dialog state transition", "from", from, "to", to)
+		}
+		return response
+	})
+}
+
+func (h *WikipediaSkill) continueReading(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	newPosition, newPositionWithinSectionBody := newBodyChopper(r.State.MaxBodyPartLen, nil).MoveToNextBodyPart(
+		page.TextForPosition(r.State.Position),
+		r.State.Position,
+		r.State.PositionWithinSectionBody)
+	return h.bodyPartResponse(page, r, newPosition, newPositionWithinSectionBody, "")
+}
+
+func (h *WikipediaSkill) stopReading(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+				ID:    "No?Okay",
+				Other: "Nein? Okay.",
+			}})),
+			ShouldSessionEnd: true,
+		},
+		SessionAttributes: r.State.WithLastQuestion(DialogStateNone).Encode(),
+	}
+}
+
+func (h *WikipediaSkill) what(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "What",
+			Other: "Wie meinen?",
+		}}))},
+		SessionAttributes: r.State.Encode(),
+	}
+}
+
+func (h *WikipediaSkill) fallback(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+				ID:    "FallbackText",
+				Other: "Meine Enzyklopädie kann hiermit nicht weiterhelfen. Aber Du kannst z.B. sagen \"Suche nach Käsekuchen\".",
+			}})),
+		},
+		SessionAttributes: r.State.Encode(),
+	}
+}
+
+func (h *WikipediaSkill) askToAnswerYesOrNo(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "PleaseAnswerYesOrNo",
+			Other: "Das habe ich nicht verstanden. Soll ich weiterlesen? Bitte antworte mit Ja oder Nein.",
+		}}))},
+		SessionAttributes: r.State.Encode(),
+	}
+}
+
+func (h *WikipediaSkill) askWhichSection(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "WhichSectionToJumpExample",
+			Other: "Zu welchem Abschnitt möchtest Du springen? Sage z.B. \"Springe zu Abschnitt 2\".",
+		}}))},
+		SessionAttributes: r.State.Encode(),
+	}
+}
+
+func (h *WikipediaSkill) offerToContinue(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "NoJumpShouldIContinue",
+			Other: "Okay. Soll ich stattdessen weiterlesen?",
+		}}))},
+		SessionAttributes: r.State.WithLastQuestion(DialogStateShouldContinue).Encode(),
+	}
+}
+
+func (h *WikipediaSkill) startAfresh(r *Request) *alexa.ResponseEnvelope {
+	return welcome(r, SessionState{LastQuestion: DialogStateNone}.WithPreferences(r.State.Preferences()))
+}
@@ -0,0 +1,119 @@
+package skill_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/skill"
+	"github.com/petergtz/alexa-wikipedia/wiki"
+	"github.com/petergtz/go-alexa"
+)
+
+type fakeWiki struct{}
+
+func (fakeWiki) GetPage(ctx context.Context, url string, localizer *locale.Localizer) (wiki.Page, error) {
+	return wiki.Page{
+		Title: url,
+		Body:  "Intro",
+		Subsections: []wiki.Section{
+			{Number: "1", Title: "A", Body: "Body A"},
+			{Number: "2", Title: "B", Body: "Body B"},
+		},
+	}, nil
+}
+
+func (w fakeWiki) SearchPage(ctx context.Context, url string, localizer *locale.Localizer) (wiki.Page, error) {
+	return w.GetPage(ctx, url, localizer)
+}
+
+type noInteractions struct{}
+
+func (noInteractions) Log(*alexa.Interaction) {}
+
+func (noInteractions) GetInteractionsByUser(userID string, newerThan time.Time) []*alexa.Interaction {
+	return nil
+}
+
+var _ = Describe("Dialog states", func() {
+	var s *skill.WikipediaSkill
+
+	BeforeEach(func() {
+		i18nBundle := newI18nBundle()
+		logger, _ := zap.NewDevelopment()
+		s = skill.NewWikipediaSkill(fakeWiki{}, i18nBundle, noInteractions{}, noInteractions{}, preferences.NewInMemoryStore(), nil, metrics.NoOp{}, nil, logger.Sugar())
+	})
+
+	answer := func(state skill.DialogState, intent string) *alexa.ResponseEnvelope {
+		return s.ProcessRequest(&alexa.RequestEnvelope{
+			Session: &alexa.Session{
+				Attributes: map[string]interface{}{
+					"word":                         "Käsekuchen",
+					"position":                     float64(0),
+					"position_within_section_body": float64(0),
+					"last_question":                string(state),
+				},
+				User: alexa.User{UserID: "some-user"},
+			},
+			Request: &alexa.Request{Type: "IntentRequest", Locale: "de-DE", Intent: alexa.Intent{Name: intent}},
+		})
+	}
+
+	lastQuestionIn := func(response *alexa.ResponseEnvelope) skill.DialogState {
+		switch state := response.SessionAttributes["last_question"].(type) {
+		case skill.DialogState:
+			return state
+		case string:
+			return skill.DialogState(state)
+		}
+		return skill.DialogStateNone
+	}
+
+	It("covers every state in the table below", func() {
+		Expect(skill.DialogStates()).To(ConsistOf(skill.DialogStateNone, skill.DialogStateShouldContinue, skill.DialogStateJumpWhere, skill.DialogStateShouldResume))
+	})
+
+	DescribeTable("answering",
+		func(state skill.DialogState, intent string, expectedState skill.DialogState, shouldEndSession bool) {
+			response := answer(state, intent)
+
+			Expect(response.Response.OutputSpeech).NotTo(BeNil())
+			Expect(response.Response.ShouldSessionEnd).To(Equal(shouldEndSession))
+			if !shouldEndSession {
+				Expect(lastQuestionIn(response)).To(Equal(expectedState))
+			}
+			Expect(skill.CanTransition(state, expectedState)).To(BeTrue())
+		},
+		Entry("none, yes", skill.DialogStateNone, "AMAZON.YesIntent", skill.DialogStateNone, false),
+		Entry("none, no", skill.DialogStateNone, "AMAZON.NoIntent", skill.DialogStateNone, false),
+		Entry("none, fallback", skill.DialogStateNone, "AMAZON.FallbackIntent", skill.DialogStateNone, false),
+		Entry("should continue, yes", skill.DialogStateShouldContinue, "AMAZON.YesIntent", skill.DialogStateShouldContinue, false),
+		Entry("should continue, no", skill.DialogStateShouldContinue, "AMAZON.NoIntent", skill.DialogStateNone, true),
+		Entry("should continue, fallback", skill.DialogStateShouldContinue, "AMAZON.FallbackIntent", skill.DialogStateShouldContinue, false),
+		Entry("jump where, yes", skill.DialogStateJumpWhere, "AMAZON.YesIntent", skill.DialogStateJumpWhere, false),
+		Entry("jump where, no", skill.DialogStateJumpWhere, "AMAZON.NoIntent", skill.DialogStateShouldContinue, false),
+		Entry("jump where, fallback", skill.DialogStateJumpWhere, "AMAZON.FallbackIntent", skill.DialogStateJumpWhere, false),
+		Entry("should resume, yes", skill.DialogStateShouldResume, "AMAZON.YesIntent", skill.DialogStateShouldContinue, false),
+		Entry("should resume, no", skill.DialogStateShouldResume, "AMAZON.NoIntent", skill.DialogStateNone, false),
+		Entry("should resume, fallback", skill.DialogStateShouldResume, "AMAZON.FallbackIntent", skill.DialogStateShouldResume, false),
+	)
+
+	It("keeps the article and position when falling back", func() {
+		state := skill.DecodeSessionState(answer(skill.DialogStateNone, "AMAZON.FallbackIntent").SessionAttributes)
+
+		Expect(state.Word).To(Equal("Käsekuchen"))
+		Expect(state.HasWord()).To(BeTrue())
+	})
+
+	It("treats an unknown last question like no question", func() {
+		Expect(answer("unknown", "AMAZON.YesIntent").Response.OutputSpeech.Text).To(Equal(
+			answer(skill.DialogStateNone, "AMAZON.YesIntent").Response.OutputSpeech.Text))
+	})
+})
@@ -0,0 +1,96 @@
+package skill
+
+import (
+	"context"
+
+	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/go-alexa"
+	"go.uber.org/zap"
+)
+
+// Request is what handlers get to see of an Alexa request. Envelope, Context and Logger are always set, Localizer and
+// State are set by the localizing and session decoding middlewares. Context carries the tracing span of the request.
+type Request struct {
+	Envelope  *alexa.RequestEnvelope
+	Context   context.Context
+	State     SessionState
+	Localizer *locale.Localizer
+	Logger    *zap.SugaredLogger
+	// InteractionAttributes are logged as interaction when a handler sets them.
+	InteractionAttributes map[string]interface{}
+}
+
+func (r *Request) Intent() alexa.Intent { return r.Envelope.Request.Intent }
+
+func (r *Request) Slot(name string) string { return r.Envelope.Request.Intent.Slots[name].Value }
+
+func (r *Request) UserID() string { return r.Envelope.Session.User.UserID }
+
+type Handler interface {
+	Handle(r *Request) *alexa.ResponseEnvelope
+}
+
+type HandlerFunc func(r *Request) *alexa.ResponseEnvelope
+
+func (f HandlerFunc) Handle(r *Request) *alexa.ResponseEnvelope { return f(r) }
+
+// Middleware wraps a Handler to do work before and after it, e.g. preparing the Request.
+type Middleware func(next Handler) Handler
+
+// Chain wraps handler in middlewares. The first middleware is the outermost one.
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Registry dispatches requests to the handler registered for their intent or, if they are not
+// IntentRequests, for their request type.
+type Registry struct {
+	intents       map[string]Handler
+	requestTypes  map[string]Handler
+	unknownIntent Handler
+}
+
+func NewRegistry(unknownIntent Handler) *Registry {
+	return &Registry{
+		intents:       make(map[string]Handler),
+		requestTypes:  make(map[string]Handler),
+		unknownIntent: unknownIntent,
+	}
+}
+
+// HandleIntent registers handler for the given intent names, replacing any handler registered before.
+func (registry *Registry) HandleIntent(handler Handler, intentNames ...string) {
+	for _, intentName := range intentNames {
+		registry.intents[intentName] = handler
+	}
+}
+
+// HandleRequestType registers handler for requests other than IntentRequests, e.g. "LaunchRequest".
+func (registry *Registry) HandleRequestType(handler Handler, requestType string) {
+	registry.requestTypes[requestType] = handler
+}
+
+// Intents returns the names of all intents a handler is registered for.
+func (registry *Registry) Intents() []string {
+	intentNames := make([]string, 0, len(registry.intents))
+	for intentName := range registry.intents {
+		intentNames = append(intentNames, intentName)
+	}
+	return intentNames
+}
+
+func (registry *Registry) Handle(r *Request) *alexa.ResponseEnvelope {
+	if r.Envelope.Request.Type == "IntentRequest" {
+		if handler, registered := registry.intents[r.Intent().Name]; registered {
+			return handler.Handle(r)
+		}
+		return registry.unknownIntent.Handle(r)
+	}
+	if handler, registered := registry.requestTypes[r.Envelope.Request.Type]; registered {
+		return handler.Handle(r)
+	}
+	return &alexa.ResponseEnvelope{Version: "1.0"}
+}
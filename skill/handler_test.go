@@ -0,0 +1,233 @@
+package skill_test
+
+import (
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/skill"
+	"github.com/petergtz/alexa-wikipedia/tracing"
+	"github.com/petergtz/go-alexa"
+)
+
+type recordingInteractionLogger struct{ interactions []*alexa.Interaction }
+
+func (l *recordingInteractionLogger) Log(interaction *alexa.Interaction) {
+	l.interactions = append(l.interactions, interaction)
+}
+
+type recordingErrorReporter struct {
+	panics   []interface{}
+	contexts []interface{}
+}
+
+func (r *recordingErrorReporter) ReportPanic(e interface{}, context interface{}) {
+	r.panics = append(r.panics, e)
+	r.contexts = append(r.contexts, context)
+}
+
+func intentRequest(intentName string) *alexa.RequestEnvelope {
+	return &alexa.RequestEnvelope{
+		Session: &alexa.Session{User: alexa.User{UserID: "some-user"}},
+		Request: &alexa.Request{Type: "IntentRequest", Locale: "de-DE", Intent: alexa.Intent{Name: intentName}},
+	}
+}
+
+func speech(text string) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0", Response: &alexa.Response{OutputSpeech: &alexa.OutputSpeech{Type: "PlainText", Text: text}}}
+}
+
+var _ = Describe("Handlers", func() {
+	Describe("Registry", func() {
+		var registry *skill.Registry
+
+		BeforeEach(func() {
+			registry = skill.NewRegistry(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { return speech("unknown") }))
+			registry.HandleIntent(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { return speech("a or b") }), "A", "B")
+			registry.HandleRequestType(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { return speech("launch") }), "LaunchRequest")
+		})
+
+		It("dispatches by intent name", func() {
+			Expect(registry.Handle(&skill.Request{Envelope: intentRequest("B")})).To(Equal(speech("a or b")))
+			Expect(registry.Intents()).To(ConsistOf("A", "B"))
+		})
+
+		It("dispatches other requests by request type", func() {
+			Expect(registry.Handle(&skill.Request{Envelope: &alexa.RequestEnvelope{Request: &alexa.Request{Type: "LaunchRequest"}}})).
+				To(Equal(speech("launch")))
+		})
+
+		It("falls back for unknown intents", func() {
+			Expect(registry.Handle(&skill.Request{Envelope: intentRequest("C")})).To(Equal(speech("unknown")))
+		})
+	})
+
+	It("recovers from panics in inner middlewares and reports them", func() {
+		errorReporter := &recordingErrorReporter{}
+		response := skill.Chain(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { return speech("") }),
+			skill.Recovering(newI18nBundle(), errorReporter),
+			skill.Localizing(newI18nBundle()),
+			func(next skill.Handler) skill.Handler {
+				return skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { panic("boom") })
+			},
+		).Handle(&skill.Request{Envelope: intentRequest("A"), Logger: zap.NewNop().Sugar()})
+
+		Expect(response.Response.OutputSpeech.Text).To(Equal("Es ist ein interner Fehler aufgetreten bei der Benutzung von Wikipedia."))
+		Expect(errorReporter.panics).To(Equal([]interface{}{"boom"}))
+	})
+
+	It("runs middlewares from the outside in", func() {
+		var calls []string
+		recording := func(name string) skill.Middleware {
+			return func(next skill.Handler) skill.Handler {
+				return skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope {
+					calls = append(calls, name+" before")
+					defer func() { calls = append(calls, name+" after") }()
+					return next.Handle(r)
+				})
+			}
+		}
+		skill.Chain(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope {
+			calls = append(calls, "handler")
+			return speech("")
+		}), recording("outer"), recording("inner")).Handle(&skill.Request{})
+
+		Expect(calls).To(Equal([]string{"outer before", "inner before", "handler", "inner after", "outer after"}))
+	})
+
+	Describe("WikipediaSkill", func() {
+		var (
+			s                 *skill.WikipediaSkill
+			interactionLogger *recordingInteractionLogger
+			errorReporter     *recordingErrorReporter
+			recorder          *metrics.Prometheus
+			spans             *tracing.InMemoryExporter
+		)
+
+		BeforeEach(func() {
+			i18nBundle := newI18nBundle()
+			interactionLogger = &recordingInteractionLogger{}
+			errorReporter = &recordingErrorReporter{}
+			recorder = metrics.NewPrometheus(metrics.DefaultBuckets)
+			spans = &tracing.InMemoryExporter{}
+			s = skill.NewWikipediaSkill(fakeWiki{}, i18nBundle, interactionLogger, noInteractions{}, preferences.NewInMemoryStore(), errorReporter, recorder, tracing.NewTracer(spans), zap.NewNop().Sugar())
+		})
+
+		It("uses handlers registered for new intents", func() {
+			s.Registry().HandleIntent(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope {
+				return speech(r.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "ShouldIContinue"}) + " " + r.State.Word)
+			}), "NewIntent")
+
+			request := intentRequest("NewIntent")
+			request.Session.Attributes = map[string]interface{}{"word": "Käsekuchen"}
+
+			Expect(s.ProcessRequest(request)).To(Equal(speech("Soll ich noch weiterlesen? Käsekuchen")))
+		})
+
+		It("recovers from panics with an internal error", func() {
+			s.Registry().HandleIntent(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { panic("boom") }), "PanickingIntent")
+
+			Expect(s.ProcessRequest(intentRequest("PanickingIntent")).Response.OutputSpeech.Text).
+				To(Equal("Es ist ein interner Fehler aufgetreten bei der Benutzung von Wikipedia."))
+		})
+
+		It("recovers from panics in middlewares with an internal error in the default locale", func() {
+			request := intentRequest("AMAZON.HelpIntent")
+			request.Request.Locale = "xx-XX"
+
+			Expect(s.ProcessRequest(request).Response.OutputSpeech.Text).
+				To(Equal("Es ist ein interner Fehler aufgetreten bei der Benutzung von Wikipedia."))
+			Expect(errorReporter.panics).To(HaveLen(1))
+		})
+
+		It("reports panics in middlewares added later", func() {
+			s.Use(func(next skill.Handler) skill.Handler {
+				return skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { panic("boom in middleware") })
+			})
+
+			Expect(s.ProcessRequest(intentRequest("AMAZON.HelpIntent")).Response.OutputSpeech.Text).
+				To(Equal("Es ist ein interner Fehler aufgetreten bei der Benutzung von Wikipedia."))
+			Expect(errorReporter.panics).To(Equal([]interface{}{"boom in middleware"}))
+		})
+
+		It("reports panics with the redacted request and the session", func() {
+			s.Registry().HandleIntent(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope { panic("boom") }), "PanickingIntent")
+			request := intentRequest("PanickingIntent")
+			request.Session.User.AccessToken = "some-token"
+			request.Session.Attributes = map[string]interface{}{"word": "Käsekuchen", "position": 3}
+
+			s.ProcessRequest(request)
+
+			Expect(errorReporter.panics).To(Equal([]interface{}{"boom"}))
+			Expect(errorReporter.contexts).To(HaveLen(1))
+			context := errorReporter.contexts[0].(skill.PanicContext)
+			Expect(context.Request.Session.User).To(Equal(alexa.User{UserID: "<REDACTED>", AccessToken: "<REDACTED>"}))
+			Expect(context.Request.Request.Intent.Name).To(Equal("PanickingIntent"))
+			Expect(context.Session.Word).To(Equal("Käsekuchen"))
+			Expect(context.Session.Position).To(Equal(3))
+			Expect(request.Session.User).To(Equal(alexa.User{UserID: "some-user", AccessToken: "some-token"}))
+		})
+
+		It("logs interactions only when handlers provide attributes", func() {
+			s.Registry().HandleIntent(skill.HandlerFunc(func(r *skill.Request) *alexa.ResponseEnvelope {
+				r.InteractionAttributes = map[string]interface{}{"Intent": r.Intent().Name}
+				return speech("")
+			}), "LoggedIntent")
+
+			s.ProcessRequest(intentRequest("AMAZON.HelpIntent"))
+			s.ProcessRequest(intentRequest("LoggedIntent"))
+
+			Expect(interactionLogger.interactions).To(HaveLen(1))
+			Expect(interactionLogger.interactions[0].Attributes).To(Equal(map[string]interface{}{"Intent": "LoggedIntent"}))
+		})
+
+		It("logs found articles", func() {
+			request := intentRequest("DefineIntent")
+			request.Request.Intent.Slots = map[string]alexa.IntentSlot{"word": {Value: "Käsekuchen"}}
+
+			s.ProcessRequest(request)
+
+			Expect(interactionLogger.interactions).To(HaveLen(1))
+			Expect(interactionLogger.interactions[0].Attributes).To(HaveKeyWithValue("ActualTitle", "Käsekuchen"))
+		})
+
+		It("records the duration per intent and how articles were found", func() {
+			request := intentRequest("DefineIntent")
+			request.Request.Intent.Slots = map[string]alexa.IntentSlot{"word": {Value: "Käsekuchen"}}
+
+			s.ProcessRequest(request)
+			s.ProcessRequest(&alexa.RequestEnvelope{
+				Session: &alexa.Session{User: alexa.User{UserID: "some-user"}},
+				Request: &alexa.Request{Type: "LaunchRequest", Locale: "de-DE"},
+			})
+
+			Expect(recorder.Text()).To(ContainSubstring(`request_duration_seconds_count{intent="DefineIntent"} 1` + "\n"))
+			Expect(recorder.Text()).To(ContainSubstring(`request_duration_seconds_count{intent="LaunchRequest"} 1` + "\n"))
+			Expect(recorder.Text()).To(ContainSubstring(`definition_lookups_total{locale="de-DE",source="get_page"} 1` + "\n"))
+		})
+
+		It("traces the history lookup and the definition lookup as children of the request", func() {
+			request := intentRequest("DefineIntent")
+			request.Request.Intent.Slots = map[string]alexa.IntentSlot{"word": {Value: "Käsekuchen"}}
+
+			s.ProcessRequest(request)
+
+			spansByName := make(map[string]tracing.SpanData)
+			for _, span := range spans.Spans() {
+				spansByName[span.Name] = span
+			}
+			Expect(spansByName).To(HaveLen(3))
+			root := spansByName["ProcessRequest"]
+			Expect(root.ParentSpanID).To(BeEmpty())
+			Expect(root.Attributes).To(Equal(map[string]interface{}{"request-type": "IntentRequest", "locale": "de-DE", "intent": "DefineIntent"}))
+			for _, name := range []string{"GetInteractionsByUser", "findDefinition"} {
+				Expect(spansByName[name].TraceID).To(Equal(root.TraceID), name)
+				Expect(spansByName[name].ParentSpanID).To(Equal(root.SpanID), name)
+			}
+			Expect(spansByName["findDefinition"].Attributes).To(HaveKeyWithValue("source", "get_page"))
+		})
+	})
+})
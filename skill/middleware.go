@@ -0,0 +1,123 @@
+package skill
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/tracing"
+	"github.com/petergtz/go-alexa"
+)
+
+// Tracing runs the handling of a request in a span, so spans started from the Request's Context are part of the same
+// trace. With a nil tracer, nothing is traced.
+func Tracing(tracer *tracing.Tracer) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *Request) *alexa.ResponseEnvelope {
+			ctx, span := tracer.Start(r.Context, "ProcessRequest")
+			defer span.End()
+			span.SetAttribute("request-type", r.Envelope.Request.Type)
+			span.SetAttribute("locale", r.Envelope.Request.Locale)
+			if r.Envelope.Request.Type == "IntentRequest" {
+				span.SetAttribute("intent", r.Intent().Name)
+			}
+			r.Context = ctx
+			return next.Handle(r)
+		})
+	}
+}
+
+// Localizing sets the Request's Localizer according to the request's locale.
+func Localizing(i18nBundle *i18n.Bundle) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *Request) *alexa.ResponseEnvelope {
+			r.Localizer = locale.NewLocalizer(i18nBundle, r.Envelope.Request.Locale, r.Logger)
+			return next.Handle(r)
+		})
+	}
+}
+
+// Measuring records how long handling a request takes, per intent or, for other requests, per request type.
+func Measuring(m metrics.Metrics) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *Request) *alexa.ResponseEnvelope {
+			startTime := time.Now()
+			defer func() {
+				name := r.Envelope.Request.Type
+				if name == "IntentRequest" {
+					name = r.Intent().Name
+				}
+				m.Duration("request_duration", time.Since(startTime), metrics.Dimensions{"intent": name})
+			}()
+			return next.Handle(r)
+		})
+	}
+}
+
+// ErrorReporter reports panics together with context that helps reproducing them.
+type ErrorReporter interface {
+	ReportPanic(e interface{}, context interface{})
+}
+
+// DefaultLocale is used for responses when the request's locale is not usable.
+const DefaultLocale = "de-DE"
+
+// Recovering turns panics into an internal error response, so the session can go on. Panics are reported to
+// errorReporter, if not nil, with the redacted request and the decoded session as context. To also recover from panics
+// in other middlewares, it should run first. When the panic happened before Localizing ran, the response is in
+// DefaultLocale.
+func Recovering(i18nBundle *i18n.Bundle, errorReporter ErrorReporter) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *Request) (response *alexa.ResponseEnvelope) {
+			defer func() {
+				if p := recover(); p != nil {
+					r.Logger.Errorw("Recovered from panic while handling request", "panic", p, "stack", string(debug.Stack()))
+					if errorReporter != nil {
+						errorReporter.ReportPanic(p, PanicContext{Request: Redacted(r.Envelope), Session: r.State})
+					}
+					if r.Localizer == nil {
+						r.Localizer = locale.NewLocalizer(i18nBundle, DefaultLocale, r.Logger)
+					}
+					response = internalError(r.Localizer)
+				}
+			}()
+			return next.Handle(r)
+		})
+	}
+}
+
+// PanicContext is what Recovering reports along with a panic.
+type PanicContext struct {
+	Request *alexa.RequestEnvelope `json:"request"`
+	Session SessionState           `json:"session"`
+}
+
+// DecodingSession sets the Request's State from the request's session attributes.
+func DecodingSession() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *Request) *alexa.ResponseEnvelope {
+			if r.Envelope.Session != nil {
+				r.State = DecodeSessionState(r.Envelope.Session.Attributes)
+			} else {
+				r.State = DecodeSessionState(nil)
+			}
+			return next.Handle(r)
+		})
+	}
+}
+
+// LoggingInteractions logs the interaction with the InteractionAttributes a handler set. Handlers that don't set
+// any are not logged.
+func LoggingInteractions(interactionLogger alexa.InteractionLogger) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *Request) *alexa.ResponseEnvelope {
+			response := next.Handle(r)
+			if r.InteractionAttributes != nil {
+				interactionLogger.Log(alexa.InteractionFrom(r.Envelope).WithAttributes(r.InteractionAttributes))
+			}
+			return response
+		})
+	}
+}
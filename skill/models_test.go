@@ -0,0 +1,74 @@
+package skill_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/skill"
+)
+
+type interactionModel struct {
+	InteractionModel struct {
+		LanguageModel struct {
+			Intents []struct {
+				Name string `json:"name"`
+			} `json:"intents"`
+		} `json:"languageModel"`
+	} `json:"interactionModel"`
+}
+
+var _ = Describe("Interaction models", func() {
+	It("only declare intents the skill handles", func() {
+		s := skill.NewWikipediaSkill(fakeWiki{}, nil, noInteractions{}, noInteractions{}, preferences.NewInMemoryStore(), nil, metrics.NoOp{}, nil, zap.NewNop().Sugar())
+		handledIntents := s.Registry().Intents()
+
+		modelFiles, e := filepath.Glob(filepath.Join("..", "models", "*.json"))
+		Expect(e).NotTo(HaveOccurred())
+		Expect(modelFiles).NotTo(BeEmpty())
+
+		for _, modelFile := range modelFiles {
+			buf, e := os.ReadFile(modelFile)
+			Expect(e).NotTo(HaveOccurred())
+			var model interactionModel
+			Expect(json.Unmarshal(buf, &model)).To(Succeed(), modelFile)
+
+			for _, intent := range model.InteractionModel.LanguageModel.Intents {
+				Expect(handledIntents).To(ContainElement(intent.Name), "%v declares %v", modelFile, intent.Name)
+			}
+		}
+	})
+
+	It("doesn't answer NavigateHome with an internal error", func() {
+		s := skill.NewWikipediaSkill(fakeWiki{}, newI18nBundle(), noInteractions{}, noInteractions{}, preferences.NewInMemoryStore(), nil, metrics.NoOp{}, nil, zap.NewNop().Sugar())
+		request := intentRequest("AMAZON.NavigateHomeIntent")
+		request.Session.Attributes = map[string]interface{}{"word": "Käsekuchen", "position": float64(1), "position_within_section_body": float64(0), "max_body_part_len": float64(2000)}
+
+		response := s.ProcessRequest(request)
+
+		Expect(response.Response.OutputSpeech.Text).To(HavePrefix("Du befindest Dich jetzt bei Wikipedia."))
+		Expect(skill.DecodeSessionState(response.SessionAttributes)).To(Equal(skill.SessionState{
+			LastQuestion:   skill.DialogStateNone,
+			MaxBodyPartLen: 2000,
+		}))
+	})
+
+	It("continues reading after unsupported built-in intents", func() {
+		s := skill.NewWikipediaSkill(fakeWiki{}, newI18nBundle(), noInteractions{}, noInteractions{}, preferences.NewInMemoryStore(), nil, metrics.NoOp{}, nil, zap.NewNop().Sugar())
+		for _, intentName := range []string{"AMAZON.LoopOnIntent", "AMAZON.LoopOffIntent", "AMAZON.ShuffleOnIntent", "AMAZON.ShuffleOffIntent"} {
+			request := intentRequest(intentName)
+			request.Session.Attributes = map[string]interface{}{"word": "Käsekuchen", "position": float64(1), "position_within_section_body": float64(0), "last_question": "should_continue"}
+
+			response := s.ProcessRequest(request)
+
+			Expect(response.Response.OutputSpeech.Text).To(Equal("Das geht bei Wikipedia-Artikeln leider nicht. Soll ich noch weiterlesen?"))
+			Expect(skill.DecodeSessionState(response.SessionAttributes).Position).To(Equal(1))
+		}
+	})
+})
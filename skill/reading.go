@@ -0,0 +1,167 @@
+package skill
+
+import (
+	"strings"
+
+	. "github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/alexa-wikipedia/apl"
+	"github.com/petergtz/go-alexa"
+)
+
+func (h *WikipediaSkill) repeat(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	l := r.Localizer
+	return withArticleRendering(r.Envelope, page, &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(newBodyChopper(r.State.MaxBodyPartLen, h.countChopperFallback).FetchBodyPart(
+				page.TextForPosition(r.State.Position),
+				r.State.PositionWithinSectionBody,
+			) +
+				"\n\n" + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+				ID:    "ShouldIContinue",
+				Other: "Soll ich noch weiterlesen?",
+			}})),
+		},
+		SessionAttributes: r.State.WithLastQuestion(DialogStateShouldContinue).Encode(),
+	}, l)
+}
+
+func (h *WikipediaSkill) previous(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	if r.State.Position == 0 && r.State.PositionWithinSectionBody == 0 {
+		return h.bodyPartResponse(page, r, 0, 0, alreadyAtBeginning(r))
+	}
+	newPosition, newPositionWithinSectionBody := page.MoveToPreviousBodyPart(
+		newBodyChopper(r.State.MaxBodyPartLen, nil), r.State.Position, r.State.PositionWithinSectionBody)
+	return h.bodyPartResponse(page, r, newPosition, newPositionWithinSectionBody, "")
+}
+
+func (h *WikipediaSkill) previousSection(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	if r.State.Position == 0 {
+		return h.bodyPartResponse(page, r, 0, 0, alreadyAtBeginning(r))
+	}
+	return h.bodyPartResponse(page, r, r.State.Position-1, 0, "")
+}
+
+func alreadyAtBeginning(r *Request) string {
+	return r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+		ID:    "AlreadyAtBeginning",
+		Other: "Wir sind bereits am Anfang des Artikels.",
+	}})
+}
+
+func (h *WikipediaSkill) startOver(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	return h.bodyPartResponse(page, r, 0, 0, "")
+}
+
+func (h *WikipediaSkill) skipSection(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	return h.bodyPartResponse(page, r, page.PositionOfNextTopLevelSection(r.State.Position), 0, "")
+}
+
+func (h *WikipediaSkill) pause(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response:          &alexa.Response{OutputSpeech: plainText(" ")},
+		SessionAttributes: r.State.WithLastQuestion(DialogStateNone).Encode(),
+	}
+}
+
+func (h *WikipediaSkill) toc(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	l := r.Localizer
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(page.Toc(l) + " " + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+				ID:    "WhichSectionToJump",
+				Other: "Zu welchem Abschnitt möchtest Du springen?",
+			}})),
+		},
+		SessionAttributes: r.State.WithLastQuestion(DialogStateJumpWhere).Encode(),
+	}
+}
+
+func (h *WikipediaSkill) whereAmI(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	l := r.Localizer
+	percentage := page.PercentageRead(r.State.Position, r.State.PositionWithinSectionBody)
+	number, titlePath := page.SectionForPosition(r.State.Position)
+	var s string
+	if number == "" {
+		s = l.MustLocalize(&LocalizeConfig{
+			DefaultMessage: &Message{
+				ID:    "YouAreInIntroduction",
+				Other: "Wir sind in der Einleitung von \"{{.ArticleTitle}}\", ungefähr {{.Percentage}} Prozent des Artikels sind gelesen.",
+			},
+			TemplateData: map[string]interface{}{"ArticleTitle": page.Title, "Percentage": percentage},
+		})
+	} else {
+		s = l.MustLocalize(&LocalizeConfig{
+			DefaultMessage: &Message{
+				ID:    "YouAreInSection",
+				Other: "Wir sind in Abschnitt {{.SectionNumber}}, {{.SectionTitle}}, ungefähr {{.Percentage}} Prozent des Artikels sind gelesen.",
+			},
+			TemplateData: map[string]interface{}{
+				"SectionNumber": number,
+				"SectionTitle":  strings.Join(titlePath, ", "),
+				"Percentage":    percentage,
+			},
+		})
+	}
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(s + " " + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+				ID:    "ShouldIContinue",
+				Other: "Soll ich noch weiterlesen?",
+			}})),
+		},
+		SessionAttributes: r.State.WithLastQuestion(DialogStateShouldContinue).Encode(),
+	}
+}
+
+func (h *WikipediaSkill) goToSectionIntent(r *Request) *alexa.ResponseEnvelope {
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	return h.goToSection(r, page, r.Slot("section_title_or_number"))
+}
+
+// aplUserEvent handles touches on the screen, see apl.RenderArticle.
+func (h *WikipediaSkill) aplUserEvent(r *Request) *alexa.ResponseEnvelope {
+	arguments := apl.Arguments(r.Envelope.Request)
+	if len(arguments) == 2 && arguments[0] == "GoToSection" {
+		page, resp := h.pageFromSession(r)
+		if resp != nil {
+			return resp
+		}
+		return h.goToSection(r, page, arguments[1])
+	}
+	r.Logger.Infow("Unknown UserEvent", "arguments", arguments)
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response:          &alexa.Response{},
+		SessionAttributes: r.State.Encode(),
+	}
+}
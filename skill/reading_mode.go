@@ -0,0 +1,114 @@
+package skill
+
+import (
+	. "github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/go-alexa"
+)
+
+func (h *WikipediaSkill) summarize(r *Request) *alexa.ResponseEnvelope {
+	r.State.ReadingMode = preferences.ReadingModeFull
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	r.State.ReadingMode = preferences.ReadingModeSummary
+	return h.bodyPartResponse(page.Summary(), r, 0, 0, r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+		ID:    "HereIsTheSummary",
+		Other: "Hier ist die Zusammenfassung:",
+	}}))
+}
+
+func (h *WikipediaSkill) readFullArticle(r *Request) *alexa.ResponseEnvelope {
+	summaryPosition := 0
+	if r.State.ReadingMode == preferences.ReadingModeSummary {
+		summaryPosition = r.State.Position
+	}
+	r.State.ReadingMode = preferences.ReadingModeFull
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	return h.bodyPartResponse(page, r,
+		page.PositionOfTopLevelSection(page.Summary().TopLevelSectionForPosition(summaryPosition)), 0, "")
+}
+
+func (h *WikipediaSkill) preferReadingMode(r *Request) *alexa.ResponseEnvelope {
+	readingMode := preferences.ReadingModeSummary
+	if r.Intent().Name == "PreferFullArticleIntent" {
+		readingMode = preferences.ReadingModeFull
+	}
+	userPreferences := h.preferencesFor(r.UserID(), r.Logger)
+	userPreferences.ReadingMode = readingMode
+	e := h.userPreferences.Put(r.UserID(), userPreferences)
+	if e != nil {
+		r.Logger.Errorw("Could not store user preferences", "error", e)
+		return internalError(r.Localizer)
+	}
+	if r.State.HasWord() && r.State.ReadingMode != readingMode {
+		// Positions in the summary and in the full article differ, so we continue at the beginning of the current
+		// top-level section in the new reading mode.
+		previousReadingMode := r.State.ReadingMode
+		r.State.ReadingMode = preferences.ReadingModeFull
+		page, resp := h.pageFromSession(r)
+		if resp != nil {
+			return resp
+		}
+		if previousReadingMode == preferences.ReadingModeSummary {
+			r.State = r.State.AtPosition(page.PositionOfTopLevelSection(page.Summary().TopLevelSectionForPosition(r.State.Position)), 0)
+		} else {
+			r.State = r.State.AtPosition(page.Summary().PositionOfTopLevelSection(page.TopLevelSectionForPosition(r.State.Position)), 0)
+		}
+	}
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+				ID:    "PreferenceSaved",
+				Other: "Alles klar, ich habe mir das gemerkt.",
+			}})),
+		},
+		SessionAttributes: r.State.WithPreferences(userPreferences).Encode(),
+	}
+}
+
+func (h *WikipediaSkill) changeBodyPartLen(r *Request) *alexa.ResponseEnvelope {
+	l := r.Localizer
+	userPreferences := h.preferencesFor(r.UserID(), r.Logger)
+	var intro string
+	if r.Intent().Name == "ShorterPartsIntent" {
+		userPreferences.MaxBodyPartLen = shorterBodyPartLen(r.State.MaxBodyPartLen)
+		intro = l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "ReadingShorterParts",
+			Other: "Alles klar, ab jetzt lese ich kürzere Teile vor.",
+		}})
+	} else {
+		userPreferences.MaxBodyPartLen = longerBodyPartLen(r.State.MaxBodyPartLen)
+		intro = l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "ReadingLongerParts",
+			Other: "Alles klar, ab jetzt lese ich längere Teile vor.",
+		}})
+	}
+	e := h.userPreferences.Put(r.UserID(), userPreferences)
+	if e != nil {
+		r.Logger.Errorw("Could not store user preferences", "error", e)
+		return internalError(l)
+	}
+	if !r.State.HasWord() {
+		return &alexa.ResponseEnvelope{Version: "1.0",
+			Response:          &alexa.Response{OutputSpeech: plainText(intro)},
+			SessionAttributes: SessionState{}.WithPreferences(userPreferences).Encode(),
+		}
+	}
+	page, resp := h.pageFromSession(r)
+	if resp != nil {
+		return resp
+	}
+	// The current body part was read with the previous length, so we must move past it using the previous
+	// length as well. Only from there on body parts are chopped with the new length.
+	newPosition, newPositionWithinSectionBody := newBodyChopper(r.State.MaxBodyPartLen, nil).MoveToNextBodyPart(
+		page.TextForPosition(r.State.Position),
+		r.State.Position,
+		r.State.PositionWithinSectionBody)
+	r.State.MaxBodyPartLen = userPreferences.MaxBodyPartLen
+	return h.bodyPartResponse(page, r, newPosition, newPositionWithinSectionBody, intro)
+}
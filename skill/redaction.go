@@ -0,0 +1,49 @@
+package skill
+
+import "github.com/petergtz/go-alexa"
+
+const redactedValue = "<REDACTED>"
+
+// Redacted returns a copy of requestEnv without user and device IDs and access tokens. requestEnv stays unchanged.
+func Redacted(requestEnv *alexa.RequestEnvelope) *alexa.RequestEnvelope {
+	if requestEnv == nil {
+		return nil
+	}
+	redacted := *requestEnv
+	if requestEnv.Session != nil {
+		session := *requestEnv.Session
+		session.User = redactedUser(session.User)
+		redacted.Session = &session
+	}
+	if requestEnv.Context != nil && requestEnv.Context.System != nil {
+		system := *requestEnv.Context.System
+		if system.APIAccessToken != "" {
+			system.APIAccessToken = redactedValue
+		}
+		if system.User != nil {
+			user := redactedUser(*system.User)
+			system.User = &user
+		}
+		if system.Device != nil {
+			device := *system.Device
+			if device.DeviceID != "" {
+				device.DeviceID = redactedValue
+			}
+			system.Device = &device
+		}
+		context := *requestEnv.Context
+		context.System = &system
+		redacted.Context = &context
+	}
+	return &redacted
+}
+
+func redactedUser(user alexa.User) alexa.User {
+	if user.UserID != "" {
+		user.UserID = redactedValue
+	}
+	if user.AccessToken != "" {
+		user.AccessToken = redactedValue
+	}
+	return user
+}
@@ -0,0 +1,47 @@
+package skill_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/skill"
+	"github.com/petergtz/go-alexa"
+)
+
+var _ = Describe("Redacted", func() {
+	It("removes IDs and tokens without changing the original", func() {
+		request := &alexa.RequestEnvelope{
+			Session: &alexa.Session{SessionID: "some-session", User: alexa.User{UserID: "some-user", AccessToken: "some-token"}},
+			Request: &alexa.Request{Type: "LaunchRequest", Locale: "de-DE"},
+			Context: &alexa.Context{System: &alexa.System{
+				APIEndpoint:    "https://api.eu.amazonalexa.com",
+				APIAccessToken: "some-api-token",
+				Device:         &alexa.Device{DeviceID: "some-device"},
+				User:           &alexa.User{UserID: "some-user"},
+			}},
+		}
+
+		redacted := skill.Redacted(request)
+
+		Expect(redacted).To(Equal(&alexa.RequestEnvelope{
+			Session: &alexa.Session{SessionID: "some-session", User: alexa.User{UserID: "<REDACTED>", AccessToken: "<REDACTED>"}},
+			Request: &alexa.Request{Type: "LaunchRequest", Locale: "de-DE"},
+			Context: &alexa.Context{System: &alexa.System{
+				APIEndpoint:    "https://api.eu.amazonalexa.com",
+				APIAccessToken: "<REDACTED>",
+				Device:         &alexa.Device{DeviceID: "<REDACTED>"},
+				User:           &alexa.User{UserID: "<REDACTED>"},
+			}},
+		}))
+		Expect(request.Session.User.UserID).To(Equal("some-user"))
+		Expect(request.Context.System.APIAccessToken).To(Equal("some-api-token"))
+		Expect(request.Context.System.Device.DeviceID).To(Equal("some-device"))
+		Expect(request.Context.System.User.UserID).To(Equal("some-user"))
+	})
+
+	It("keeps missing parts missing", func() {
+		Expect(skill.Redacted(nil)).To(BeNil())
+		Expect(skill.Redacted(&alexa.RequestEnvelope{Request: &alexa.Request{Type: "LaunchRequest"}})).
+			To(Equal(&alexa.RequestEnvelope{Request: &alexa.Request{Type: "LaunchRequest"}}))
+	})
+})
@@ -0,0 +1,53 @@
+package skill
+
+import (
+	. "github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/petergtz/go-alexa"
+)
+
+// reprompts are spoken when the user doesn't answer a question within a few seconds. Consecutive questions of the
+// same kind get the next variant, so the skill doesn't sound like a broken record.
+var reprompts = map[DialogState][]*Message{
+	DialogStateShouldContinue: {
+		{ID: "RepromptShouldContinue1", Other: "Soll ich weiterlesen?"},
+		{ID: "RepromptShouldContinue2", Other: "Möchtest Du, dass ich weiterlese? Sage einfach Ja oder Nein."},
+		{ID: "RepromptShouldContinue3", Other: "Sage \"Weiter\", um weiterzulesen, oder \"Stopp\", um aufzuhören."},
+	},
+	DialogStateJumpWhere: {
+		{ID: "RepromptJumpWhere1", Other: "Zu welchem Abschnitt möchtest Du springen?"},
+		{ID: "RepromptJumpWhere2", Other: "Sage z.B. \"Springe zu Abschnitt 2\"."},
+		{ID: "RepromptJumpWhere3", Other: "Nenne die Nummer oder den Titel eines Abschnitts, zu dem ich springen soll."},
+	},
+	DialogStateShouldResume: {
+		{ID: "RepromptShouldResume1", Other: "Soll ich dort weiterlesen, wo wir aufgehört haben?"},
+		{ID: "RepromptShouldResume2", Other: "Sage Ja, um weiterzulesen, oder suche nach einem neuen Begriff."},
+	},
+}
+
+// Reprompting attaches a reprompt to responses that ask a question and keep the session open. If the user
+// still doesn't answer, Alexa ends the session and the skill gets a chance to bookmark the current position,
+// see sessionEnded. It relies on Localizing and DecodingSession to run before.
+func Reprompting() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *Request) *alexa.ResponseEnvelope {
+			response := next.Handle(r)
+			if response == nil || response.Response == nil || response.Response.ShouldSessionEnd || response.Response.Reprompt != nil {
+				return response
+			}
+			state := DecodeSessionState(response.SessionAttributes)
+			variants := reprompts[state.LastQuestion]
+			if len(variants) == 0 {
+				return response
+			}
+			state.Reprompts = 0
+			if state.LastQuestion == r.State.LastQuestion {
+				state.Reprompts = r.State.Reprompts + 1
+			}
+			response.SessionAttributes = state.Encode()
+			response.Response.Reprompt = &alexa.Reprompt{OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{
+				DefaultMessage: variants[state.Reprompts%len(variants)],
+			}))}
+			return response
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package skill_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/skill"
+	"github.com/petergtz/go-alexa"
+)
+
+var _ = Describe("Reprompts", func() {
+	var (
+		s                *skill.WikipediaSkill
+		attributes       map[string]interface{}
+		preferencesStore *preferences.InMemoryStore
+	)
+
+	BeforeEach(func() {
+		preferencesStore = preferences.NewInMemoryStore()
+		s = skill.NewWikipediaSkill(fakeWiki{}, newI18nBundle(), noInteractions{}, noInteractions{}, preferencesStore, nil, metrics.NoOp{}, nil, zap.NewNop().Sugar())
+		attributes = nil
+	})
+
+	send := func(request *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
+		request.Session.Attributes = attributes
+		response := s.ProcessRequest(request)
+		attributes = response.SessionAttributes
+		return response
+	}
+
+	define := func() *alexa.ResponseEnvelope {
+		request := intentRequest("DefineIntent")
+		request.Request.Intent.Slots = map[string]alexa.IntentSlot{"word": {Value: "Käsekuchen"}}
+		return send(request)
+	}
+
+	repromptOf := func(response *alexa.ResponseEnvelope) string {
+		Expect(response.Response.Reprompt).NotTo(BeNil())
+		return response.Response.Reprompt.OutputSpeech.Text
+	}
+
+	It("varies reprompts when the same question is asked repeatedly", func() {
+		Expect(repromptOf(define())).To(Equal("Soll ich weiterlesen?"))
+		Expect(repromptOf(send(intentRequest("AMAZON.RepeatIntent")))).To(Equal("Möchtest Du, dass ich weiterlese? Sage einfach Ja oder Nein."))
+		Expect(repromptOf(send(intentRequest("AMAZON.RepeatIntent")))).To(Equal("Sage \"Weiter\", um weiterzulesen, oder \"Stopp\", um aufzuhören."))
+		Expect(repromptOf(send(intentRequest("AMAZON.RepeatIntent")))).To(Equal("Soll ich weiterlesen?"))
+		Expect(repromptOf(send(intentRequest("TocIntent")))).To(Equal("Zu welchem Abschnitt möchtest Du springen?"))
+	})
+
+	It("doesn't reprompt when there is no question", func() {
+		define()
+		Expect(send(intentRequest("AMAZON.PauseIntent")).Response.Reprompt).To(BeNil())
+		Expect(send(intentRequest("AMAZON.HelpIntent")).Response.Reprompt).To(BeNil())
+	})
+
+	Context("when the session times out while reading", func() {
+		BeforeEach(func() {
+			define()
+			send(intentRequest("AMAZON.NextIntent"))
+			send(&alexa.RequestEnvelope{
+				Session: &alexa.Session{User: alexa.User{UserID: "some-user"}},
+				Request: &alexa.Request{Type: "SessionEndedRequest", Locale: "de-DE", Reason: "EXCEEDED_MAX_REPROMPTS"},
+			})
+			attributes = nil
+		})
+
+		It("offers to resume reading in the next session", func() {
+			response := send(&alexa.RequestEnvelope{
+				Session: &alexa.Session{New: true, User: alexa.User{UserID: "some-user"}},
+				Request: &alexa.Request{Type: "LaunchRequest", Locale: "de-DE"},
+			})
+			Expect(response.Response.OutputSpeech.Text).To(ContainSubstring("Beim letzten Mal haben wir über \"Käsekuchen\" gelesen."))
+			Expect(repromptOf(response)).To(Equal("Soll ich dort weiterlesen, wo wir aufgehört haben?"))
+
+			response = send(intentRequest("AMAZON.YesIntent"))
+			Expect(response.Response.OutputSpeech.Text).To(ContainSubstring("Body B"))
+		})
+
+		It("offers it only once", func() {
+			send(&alexa.RequestEnvelope{
+				Session: &alexa.Session{New: true, User: alexa.User{UserID: "some-user"}},
+				Request: &alexa.Request{Type: "LaunchRequest", Locale: "de-DE"},
+			})
+			response := send(&alexa.RequestEnvelope{
+				Session: &alexa.Session{New: true, User: alexa.User{UserID: "some-user"}},
+				Request: &alexa.Request{Type: "LaunchRequest", Locale: "de-DE"},
+			})
+			Expect(response.Response.OutputSpeech.Text).To(HavePrefix("Du befindest Dich jetzt bei Wikipedia."))
+		})
+	})
+})
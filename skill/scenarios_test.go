@@ -0,0 +1,101 @@
+package skill_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"github.com/petergtz/alexa-wikipedia/apl"
+	"github.com/petergtz/alexa-wikipedia/interactions"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/simulator"
+	"github.com/petergtz/alexa-wikipedia/skill"
+)
+
+func runScenario(path string) (*simulator.Scenario, []simulator.Result) {
+	scenario, e := simulator.LoadScenario(path)
+	Expect(e).NotTo(HaveOccurred())
+	model, e := simulator.LoadInteractionModel(filepath.Join("..", "models"), scenario.Locale)
+	Expect(e).NotTo(HaveOccurred())
+	store := interactions.NewInMemoryStore()
+	s := skill.NewWikipediaSkill(scenario.Wiki(), newI18nBundle(), store, store, preferences.NewInMemoryStore(), nil, metrics.NoOp{}, nil, zap.NewNop().Sugar())
+	return scenario, scenario.Run(s, simulator.NewResolver(model, scenario.Locale))
+}
+
+func describe(turn simulator.Turn) string {
+	switch {
+	case turn.User != "":
+		return fmt.Sprintf("user: %v", turn.User)
+	case turn.Intent != "":
+		return fmt.Sprintf("intent: %v", turn.Intent)
+	case len(turn.Event) > 0:
+		return fmt.Sprintf("event: %v", turn.Event)
+	default:
+		return fmt.Sprintf("session_ended: %v", turn.SessionEnded)
+	}
+}
+
+var _ = Describe("Scenarios", func() {
+	scenarioFiles, e := filepath.Glob(filepath.Join("testdata", "scenarios", "*.yaml"))
+	if e != nil {
+		panic(e)
+	}
+
+	for _, scenarioFile := range scenarioFiles {
+		scenarioFile := scenarioFile
+		It(strings.TrimSuffix(filepath.Base(scenarioFile), ".yaml"), func() {
+			scenario, results := runScenario(scenarioFile)
+
+			Expect(results).To(HaveLen(len(scenario.Turns)), "scenario %q stopped early", scenario.Name)
+			for i, result := range results {
+				Expect(result.Failures).To(BeEmpty(), "%v, turn %v (%v)", scenario.Name, i+1, describe(result.Turn))
+			}
+		})
+	}
+
+	It("cover every intent, request type and dialog state transition", func() {
+		var (
+			covered = map[string]bool{}
+			s       = skill.NewWikipediaSkill(fakeWiki{}, nil, noInteractions{}, noInteractions{}, preferences.NewInMemoryStore(), nil, metrics.NoOp{}, nil, zap.NewNop().Sugar())
+		)
+		for _, scenarioFile := range scenarioFiles {
+			_, results := runScenario(scenarioFile)
+			for _, result := range results {
+				if result.Request == nil || result.Response == nil {
+					continue
+				}
+				from := skill.DecodeSessionState(result.Request.Session.Attributes).LastQuestion
+				to := skill.DecodeSessionState(result.Response.SessionAttributes).LastQuestion
+				covered[result.Request.Request.Type] = true
+				covered[result.Request.Request.Intent.Name] = true
+				covered[fmt.Sprintf("%v in %v", result.Request.Request.Intent.Name, from)] = true
+				covered[fmt.Sprintf("%v -> %v", from, to)] = true
+			}
+		}
+
+		expected := []string{"LaunchRequest", "SessionEndedRequest", apl.UserEventType}
+		expected = append(expected, s.Registry().Intents()...)
+		for _, from := range skill.DialogStates() {
+			for _, answer := range []string{"AMAZON.YesIntent", "AMAZON.NoIntent", "AMAZON.FallbackIntent"} {
+				expected = append(expected, fmt.Sprintf("%v in %v", answer, from))
+			}
+			for _, to := range skill.DialogStates() {
+				if skill.CanTransition(from, to) {
+					expected = append(expected, fmt.Sprintf("%v -> %v", from, to))
+				}
+			}
+		}
+		var missing []string
+		for _, e := range expected {
+			if !covered[e] {
+				missing = append(missing, e)
+			}
+		}
+		Expect(missing).To(BeEmpty(), "not covered by any scenario in testdata/scenarios")
+	})
+})
@@ -0,0 +1,194 @@
+package skill
+
+import (
+	"math"
+
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/wiki"
+)
+
+// SessionStateVersion is the version of the session attribute layout written by Encode.
+//
+// Version 0 is the untyped layout used before session attributes were versioned. It has the same keys, but no
+// "version" key and it does not guarantee that positions are present whenever a word is.
+const SessionStateVersion = 1
+
+// SessionState is everything the skill remembers between requests within a session.
+type SessionState struct {
+	// Word is the search term of the article that is currently read. Empty when no article was looked up yet.
+	Word                      string
+	Position                  int
+	PositionWithinSectionBody int
+	LastQuestion              DialogState
+	ReadingMode               string
+	MaxBodyPartLen            int
+	// Reprompts counts how many responses in a row asked the LastQuestion.
+	Reprompts int
+}
+
+// migrations[v] migrates session attributes from version v to version v+1.
+var migrations = []func(attributes map[string]interface{}) map[string]interface{}{
+	func(attributes map[string]interface{}) map[string]interface{} {
+		migrated := make(map[string]interface{}, len(attributes)+1)
+		for key, value := range attributes {
+			migrated[key] = value
+		}
+		if migrated["word"] != nil {
+			if migrated["position"] == nil {
+				migrated["position"] = 0
+			}
+			if migrated["position_within_section_body"] == nil {
+				migrated["position_within_section_body"] = 0
+			}
+		}
+		migrated["version"] = 1
+		return migrated
+	},
+}
+
+// DecodeSessionState never fails. Attributes of older versions are migrated first. Missing or malformed
+// attributes are replaced by safe defaults, i.e. the state of a session in which no article was looked up yet.
+func DecodeSessionState(attributes map[string]interface{}) SessionState {
+	version, _ := intFrom(attributes["version"])
+	if version < 0 {
+		version = 0
+	}
+	for ; version < len(migrations) && version < SessionStateVersion; version++ {
+		attributes = migrations[version](attributes)
+	}
+
+	var state SessionState
+	state.LastQuestion = DialogStateNone
+	if lastQuestion, isDialogState := dialogStateFrom(attributes["last_question"]); isDialogState {
+		state.LastQuestion = lastQuestion
+	}
+	if readingMode, isString := attributes["reading_mode"].(string); isString && readingMode == preferences.ReadingModeSummary {
+		state.ReadingMode = readingMode
+	}
+	if maxBodyPartLen, isInt := intFrom(attributes["max_body_part_len"]); isInt && isValidBodyPartLen(maxBodyPartLen) {
+		state.MaxBodyPartLen = maxBodyPartLen
+	}
+	if reprompts, isInt := intFrom(attributes["reprompts"]); isInt && reprompts > 0 && state.LastQuestion != DialogStateNone {
+		state.Reprompts = reprompts
+	}
+
+	word, isString := attributes["word"].(string)
+	if !isString || word == "" {
+		return state
+	}
+	position, hasPosition := intFrom(attributes["position"])
+	positionWithinSectionBody, hasPositionWithinSectionBody := intFrom(attributes["position_within_section_body"])
+	if !hasPosition || !hasPositionWithinSectionBody || position < 0 || positionWithinSectionBody < 0 {
+		position, positionWithinSectionBody = 0, 0
+	}
+	state.Word = word
+	state.Position = position
+	state.PositionWithinSectionBody = positionWithinSectionBody
+	return state
+}
+
+// Encode returns the session attributes for this state in the layout of SessionStateVersion.
+func (s SessionState) Encode() map[string]interface{} {
+	attributes := map[string]interface{}{"version": SessionStateVersion}
+	if s.Word != "" {
+		attributes["word"] = s.Word
+		attributes["position"] = s.Position
+		attributes["position_within_section_body"] = s.PositionWithinSectionBody
+	}
+	if s.LastQuestion != DialogStateNone && s.LastQuestion != "" {
+		attributes["last_question"] = string(s.LastQuestion)
+	}
+	if s.ReadingMode != preferences.ReadingModeFull {
+		attributes["reading_mode"] = s.ReadingMode
+	}
+	if s.MaxBodyPartLen != 0 && s.MaxBodyPartLen != defaultMaxBodyPartLen {
+		attributes["max_body_part_len"] = s.MaxBodyPartLen
+	}
+	if s.Reprompts > 0 && s.LastQuestion != DialogStateNone && s.LastQuestion != "" {
+		attributes["reprompts"] = s.Reprompts
+	}
+	return attributes
+}
+
+func (s SessionState) HasWord() bool { return s.Word != "" }
+
+func (s SessionState) Preferences() preferences.Preferences {
+	return preferences.Preferences{ReadingMode: s.ReadingMode, MaxBodyPartLen: s.MaxBodyPartLen}
+}
+
+// WithPreferences adds preferences, e.g. summary mode, to the state.
+func (s SessionState) WithPreferences(p preferences.Preferences) SessionState {
+	s.ReadingMode = p.ReadingMode
+	s.MaxBodyPartLen = p.MaxBodyPartLen
+	return s
+}
+
+// WithReadingMode returns the state with a reading mode that only applies to this session.
+func (s SessionState) WithReadingMode(readingMode string) SessionState {
+	s.ReadingMode = readingMode
+	return s
+}
+
+// AtPosition returns the state with the position of the body part that is read next.
+func (s SessionState) AtPosition(position int, positionWithinSectionBody int) SessionState {
+	s.Position = position
+	s.PositionWithinSectionBody = positionWithinSectionBody
+	return s
+}
+
+// WithLastQuestion returns the state with the question the skill asks in its response.
+func (s SessionState) WithLastQuestion(lastQuestion DialogState) SessionState {
+	s.LastQuestion = lastQuestion
+	return s
+}
+
+// withinPage moves positions that don't exist in page, e.g. because the article changed on Wikipedia in the
+// meantime, back to the beginning of the article.
+func (s SessionState) withinPage(page wiki.Page) SessionState {
+	if s.Position > page.NumPositions() || s.PositionWithinSectionBody > len(page.TextForPosition(s.Position)) {
+		return s.AtPosition(0, 0)
+	}
+	return s
+}
+
+func dialogStateFrom(attribute interface{}) (DialogState, bool) {
+	var state DialogState
+	switch s := attribute.(type) {
+	case DialogState:
+		state = s
+	case string:
+		state = DialogState(s)
+	default:
+		return "", false
+	}
+	for _, declared := range DialogStates() {
+		if state == declared {
+			return state, true
+		}
+	}
+	return "", false
+}
+
+func isValidBodyPartLen(maxBodyPartLen int) bool {
+	for _, bodyPartLen := range bodyPartLens {
+		if bodyPartLen == maxBodyPartLen {
+			return true
+		}
+	}
+	return false
+}
+
+// intFrom handles attributes set within the skill as well as attributes that went through JSON decoding.
+// Fractions are truncated. Values that don't fit into an int are rejected.
+func intFrom(attribute interface{}) (int, bool) {
+	switch i := attribute.(type) {
+	case float64:
+		if math.IsNaN(i) || i < math.MinInt32 || i > math.MaxInt32 {
+			return 0, false
+		}
+		return int(i), true
+	case int:
+		return i, true
+	}
+	return 0, false
+}
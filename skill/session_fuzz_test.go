@@ -0,0 +1,113 @@
+package skill_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/skill"
+	"github.com/petergtz/go-alexa"
+)
+
+var sessionAttributesSeeds = []string{
+	`{}`,
+	`{"word":"Käsekuchen","position":0,"position_within_section_body":0,"last_question":"should_continue"}`,
+	`{"version":1,"word":"Käsekuchen","position":3,"position_within_section_body":1200,"last_question":"jump_where","reading_mode":"summary","max_body_part_len":2000}`,
+	`{"word":"Käsekuchen"}`,
+	`{"word":null,"position":"3","position_within_section_body":[]}`,
+	`{"word":"Käsekuchen","position":1e300,"position_within_section_body":-5}`,
+	`{"version":"1","last_question":7,"reading_mode":{},"max_body_part_len":1e-300}`,
+	`{"version":99,"word":"","position":1.5}`,
+}
+
+func FuzzDecodeSessionState(f *testing.F) {
+	for _, seed := range sessionAttributesSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var attributes map[string]interface{}
+		if json.Unmarshal(data, &attributes) != nil {
+			return
+		}
+		state := skill.DecodeSessionState(attributes)
+		if state.Position < 0 || state.PositionWithinSectionBody < 0 {
+			t.Fatalf("negative position in %#v", state)
+		}
+		if !state.HasWord() && (state.Position != 0 || state.PositionWithinSectionBody != 0) {
+			t.Fatalf("position without word in %#v", state)
+		}
+
+		buf, e := json.Marshal(state.Encode())
+		if e != nil {
+			t.Fatal(e)
+		}
+		var encoded map[string]interface{}
+		if e := json.Unmarshal(buf, &encoded); e != nil {
+			t.Fatal(e)
+		}
+		if decoded := skill.DecodeSessionState(encoded); !reflect.DeepEqual(decoded, state) {
+			t.Fatalf("%#v changed to %#v after encoding and decoding", state, decoded)
+		}
+	})
+}
+
+// failingErrorReporter fails the test on panics, which Recovering would turn into a response otherwise.
+type failingErrorReporter struct{ t *testing.T }
+
+func (r failingErrorReporter) ReportPanic(e interface{}, context interface{}) {
+	r.t.Fatalf("panic: %v, context: %#v", e, context)
+}
+
+func FuzzProcessRequestWithArbitrarySessionAttributes(f *testing.F) {
+	for _, seed := range sessionAttributesSeeds {
+		f.Add([]byte(seed))
+	}
+	i18nBundle := newI18nBundle()
+	intents := []string{
+		"AMAZON.YesIntent", "AMAZON.NoIntent", "AMAZON.FallbackIntent", "AMAZON.ResumeIntent", "AMAZON.RepeatIntent",
+		"AMAZON.NextIntent", "AMAZON.PreviousIntent", "PreviousSectionIntent", "AMAZON.StartOverIntent",
+		"SkipSectionIntent", "SummarizeIntent", "ReadFullArticleIntent", "ShorterPartsIntent", "LongerPartsIntent",
+		"AMAZON.PauseIntent", "TocIntent", "WhereAmIIntent", "GoToSectionIntent",
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s := skill.NewWikipediaSkill(fakeWiki{}, i18nBundle, noInteractions{}, noInteractions{}, preferences.NewInMemoryStore(), failingErrorReporter{t}, metrics.NoOp{}, nil, zap.NewNop().Sugar())
+		for _, intent := range intents {
+			var attributes map[string]interface{}
+			if json.Unmarshal(data, &attributes) != nil {
+				return
+			}
+			response := s.ProcessRequest(&alexa.RequestEnvelope{
+				Session: &alexa.Session{Attributes: attributes, User: alexa.User{UserID: "some-user"}},
+				Request: &alexa.Request{Type: "IntentRequest", Locale: "de-DE", Intent: alexa.Intent{
+					Name:  intent,
+					Slots: map[string]alexa.IntentSlot{"section_title_or_number": {Value: "2"}},
+				}},
+			})
+			if response == nil || response.Response == nil {
+				t.Fatalf("no response to %v", intent)
+			}
+			if response.Response.ShouldSessionEnd {
+				continue
+			}
+			buf, e := json.Marshal(response.SessionAttributes)
+			if e != nil {
+				t.Fatal(e)
+			}
+			var encoded map[string]interface{}
+			if e := json.Unmarshal(buf, &encoded); e != nil {
+				t.Fatal(e)
+			}
+			if encoded["version"] != float64(skill.SessionStateVersion) {
+				t.Fatalf("session attributes %v of response to %v are not in the current layout", encoded, intent)
+			}
+			if state := skill.DecodeSessionState(encoded); !reflect.DeepEqual(state.Encode(), response.SessionAttributes) {
+				t.Fatalf("session attributes %v of response to %v don't decode to the same state", response.SessionAttributes, intent)
+			}
+		}
+	})
+}
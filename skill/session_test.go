@@ -0,0 +1,89 @@
+package skill_test
+
+import (
+	"encoding/json"
+	"math"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/skill"
+)
+
+var _ = Describe("SessionState", func() {
+	roundTrip := func(state skill.SessionState) skill.SessionState {
+		buf, e := json.Marshal(state.Encode())
+		Expect(e).NotTo(HaveOccurred())
+		var attributes map[string]interface{}
+		Expect(json.Unmarshal(buf, &attributes)).To(Succeed())
+		return skill.DecodeSessionState(attributes)
+	}
+
+	It("survives encoding, JSON marshalling and decoding", func() {
+		state := skill.SessionState{
+			Word:                      "Käsekuchen",
+			Position:                  3,
+			PositionWithinSectionBody: 1200,
+			LastQuestion:              skill.DialogStateJumpWhere,
+			ReadingMode:               preferences.ReadingModeSummary,
+			MaxBodyPartLen:            2000,
+		}
+		Expect(roundTrip(state)).To(Equal(state))
+	})
+
+	It("writes the current version", func() {
+		Expect(skill.SessionState{}.Encode()).To(HaveKeyWithValue("version", skill.SessionStateVersion))
+	})
+
+	It("defaults to a session without an article", func() {
+		Expect(skill.DecodeSessionState(nil)).To(Equal(skill.SessionState{LastQuestion: skill.DialogStateNone}))
+	})
+
+	Context("unversioned attributes", func() {
+		It("migrates them", func() {
+			Expect(skill.DecodeSessionState(map[string]interface{}{
+				"word":                         "Käsekuchen",
+				"position":                     float64(2),
+				"position_within_section_body": float64(100),
+				"last_question":                "should_continue",
+				"max_body_part_len":            float64(1000),
+			})).To(Equal(skill.SessionState{
+				Word:                      "Käsekuchen",
+				Position:                  2,
+				PositionWithinSectionBody: 100,
+				LastQuestion:              skill.DialogStateShouldContinue,
+				MaxBodyPartLen:            1000,
+			}))
+		})
+
+		It("starts at the beginning of the article when positions are missing", func() {
+			Expect(skill.DecodeSessionState(map[string]interface{}{"word": "Käsekuchen"})).To(Equal(skill.SessionState{
+				Word:         "Käsekuchen",
+				LastQuestion: skill.DialogStateNone,
+			}))
+		})
+	})
+
+	It("replaces malformed attributes by safe defaults", func() {
+		Expect(skill.DecodeSessionState(map[string]interface{}{
+			"version":                      float64(1),
+			"word":                         "Käsekuchen",
+			"position":                     float64(-1),
+			"position_within_section_body": math.NaN(),
+			"last_question":                "what_is_the_meaning_of_life",
+			"reading_mode":                 "backwards",
+			"max_body_part_len":            float64(1),
+		})).To(Equal(skill.SessionState{
+			Word:         "Käsekuchen",
+			LastQuestion: skill.DialogStateNone,
+		}))
+	})
+
+	It("ignores positions without a word", func() {
+		Expect(skill.DecodeSessionState(map[string]interface{}{
+			"word":     float64(42),
+			"position": float64(3),
+		})).To(Equal(skill.SessionState{LastQuestion: skill.DialogStateNone}))
+	})
+})
@@ -1,13 +1,15 @@
 package skill
 
 import (
-	"strings"
-	"sync"
-	"time"
+	"context"
 
+	"github.com/petergtz/alexa-wikipedia/apl"
 	"github.com/petergtz/alexa-wikipedia/bodychoppers/dumb"
 	"github.com/petergtz/alexa-wikipedia/bodychoppers/paragraph"
 	"github.com/petergtz/alexa-wikipedia/locale"
+	"github.com/petergtz/alexa-wikipedia/metrics"
+	"github.com/petergtz/alexa-wikipedia/preferences"
+	"github.com/petergtz/alexa-wikipedia/tracing"
 	"go.uber.org/zap"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -21,8 +23,11 @@ type WikipediaSkill struct {
 	i18nBundle         *i18n.Bundle
 	interactionLogger  alexa.InteractionLogger
 	interactionHistory alexa.InteractionHistory
-	bodyChopper        wiki.BodyChopper
+	userPreferences    preferences.Store
+	metrics            metrics.Metrics
 	logger             *zap.SugaredLogger
+	registry           *Registry
+	middlewares        []Middleware
 }
 
 func NewWikipediaSkill(
@@ -30,513 +35,191 @@ func NewWikipediaSkill(
 	i18nBundle *i18n.Bundle,
 	interactionLogger alexa.InteractionLogger,
 	interactionHistory alexa.InteractionHistory,
+	userPreferences preferences.Store,
+	errorReporter ErrorReporter,
+	metrics metrics.Metrics,
+	tracer *tracing.Tracer,
 	logger *zap.SugaredLogger,
 ) *WikipediaSkill {
-	return &WikipediaSkill{
+	h := &WikipediaSkill{
 		i18nBundle:         i18nBundle,
 		wiki:               wiki,
 		interactionLogger:  interactionLogger,
 		interactionHistory: interactionHistory,
-		bodyChopper: &paragraph.BodyChopper{
-			MaxBodyPartLen: 6000,
-			Fallback: dumb.BodyChopper{
-				MaxBodyPartLen: 6000,
-			},
-		},
-		logger: logger,
+		userPreferences:    userPreferences,
+		metrics:            metrics,
+		logger:             logger,
+	}
+	h.registry = NewRegistry(HandlerFunc(func(r *Request) *alexa.ResponseEnvelope { return internalError(r.Localizer) }))
+	h.registry.HandleRequestType(HandlerFunc(h.launch), "LaunchRequest")
+	h.registry.HandleRequestType(HandlerFunc(h.aplUserEvent), apl.UserEventType)
+	h.registry.HandleRequestType(HandlerFunc(h.sessionEnded), "SessionEndedRequest")
+	h.registry.HandleIntent(HandlerFunc(h.define), "DefineIntent")
+	h.registry.HandleIntent(HandlerFunc(h.spell), "SpellIntent")
+	h.registry.HandleIntent(h.answer(func(d dialogStateDefinition) dialogHandler { return d.onYes }), "AMAZON.YesIntent")
+	h.registry.HandleIntent(h.answer(func(d dialogStateDefinition) dialogHandler { return d.onNo }), "AMAZON.NoIntent")
+	h.registry.HandleIntent(h.answer(func(d dialogStateDefinition) dialogHandler { return d.onFallback }), "AMAZON.FallbackIntent")
+	h.registry.HandleIntent(HandlerFunc(h.continueReading), "AMAZON.ResumeIntent", "AMAZON.NextIntent")
+	h.registry.HandleIntent(HandlerFunc(h.repeat), "AMAZON.RepeatIntent")
+	h.registry.HandleIntent(HandlerFunc(h.previous), "AMAZON.PreviousIntent")
+	h.registry.HandleIntent(HandlerFunc(h.previousSection), "PreviousSectionIntent")
+	h.registry.HandleIntent(HandlerFunc(h.startOver), "AMAZON.StartOverIntent")
+	h.registry.HandleIntent(HandlerFunc(h.skipSection), "SkipSectionIntent")
+	h.registry.HandleIntent(HandlerFunc(h.summarize), "SummarizeIntent")
+	h.registry.HandleIntent(HandlerFunc(h.readFullArticle), "ReadFullArticleIntent")
+	h.registry.HandleIntent(HandlerFunc(h.preferReadingMode), "PreferSummaryIntent", "PreferFullArticleIntent")
+	h.registry.HandleIntent(HandlerFunc(h.changeBodyPartLen), "ShorterPartsIntent", "LongerPartsIntent")
+	h.registry.HandleIntent(HandlerFunc(h.pause), "AMAZON.PauseIntent")
+	h.registry.HandleIntent(HandlerFunc(h.toc), "TocIntent")
+	h.registry.HandleIntent(HandlerFunc(h.whereAmI), "WhereAmIIntent")
+	h.registry.HandleIntent(HandlerFunc(h.goToSectionIntent), "GoToSectionIntent")
+	h.registry.HandleIntent(HandlerFunc(h.help), "AMAZON.HelpIntent")
+	h.registry.HandleIntent(HandlerFunc(h.stop), "AMAZON.CancelIntent", "AMAZON.StopIntent")
+	h.registry.HandleIntent(HandlerFunc(h.navigateHome), "AMAZON.NavigateHomeIntent")
+	h.registry.HandleIntent(HandlerFunc(h.notSupported),
+		"AMAZON.LoopOnIntent", "AMAZON.LoopOffIntent", "AMAZON.ShuffleOnIntent", "AMAZON.ShuffleOffIntent")
+	h.middlewares = []Middleware{
+		Recovering(i18nBundle, errorReporter),
+		Tracing(tracer),
+		Localizing(i18nBundle),
+		Measuring(metrics),
+		DecodingSession(),
+		Reprompting(),
+		LoggingInteractions(interactionLogger),
 	}
+	return h
 }
 
-func (h *WikipediaSkill) ProcessRequest(requestEnv *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
-	logger := h.logger.With("alexa-request-id", requestEnv.Request.RequestID, "user-id", requestEnv.Session.User.UserID)
-	l := locale.NewLocalizer(h.i18nBundle, requestEnv.Request.Locale, logger)
-
-	switch requestEnv.Request.Type {
-
-	case "LaunchRequest":
-		return &alexa.ResponseEnvelope{Version: "1.0",
-			Response: &alexa.Response{
-				OutputSpeech: plainText(
-					l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID: "YouAreAtWikipediaNow",
-					}})),
-			},
-			SessionAttributes: map[string]interface{}{
-				"last_question": "none",
-			},
-		}
-
-	case "IntentRequest":
-		intent := requestEnv.Request.Intent
-		switch intent.Name {
-		case "DefineIntent":
-			logger.Debugw("DefineIntent begin")
-
-			var (
-				userInteractions []*alexa.Interaction
-				wg               sync.WaitGroup
-			)
-
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				logger.Debugw("Before GetInteractionsByUser")
-				startTime := time.Now()
-				userInteractions = h.interactionHistory.GetInteractionsByUser(requestEnv.Session.User.UserID, time.Now().Add(-10*time.Second))
-				logger.Debugw("After GetInteractionsByUser", "duration", time.Since(startTime).String())
-			}()
-
-			startTime := time.Now()
-			definition, e := h.findDefinition(intent.Slots["word"].Value, l)
-			logger.Debugw("findDefinition finished", "duration", time.Since(startTime).String())
-			if e != nil {
-				logger.Errorw("Could not get Wikipedia page", "error", e)
-				return internalError(l)
-			}
-			if definition == nil {
-				h.interactionLogger.Log(alexa.InteractionFrom(requestEnv).WithAttributes(map[string]interface{}{
-					"Intent":      intent.Name,
-					"SearchQuery": intent.Slots["word"].Value,
-					"ActualTitle": "NOT_FOUND",
-				}))
-
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{
-						OutputSpeech: plainText(
-							l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-								ID:    "CouldNotFindExpression",
-								Other: "Diesen Begriff konnte ich bei Wikipedia leider nicht finden. Versuche es doch mit einem anderen Begriff.",
-							}}),
-						),
-					},
-				}
-			}
-			wg.Wait()
-			if titleWasAlreadyRecentlyFound(definition.Title, userInteractions) {
-				logger.Debug("title was recently found", "title", definition.Title)
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{
-						OutputSpeech: plainText(
-							l.MustLocalize(&LocalizeConfig{
-								DefaultMessage: &Message{
-									ID: "SpellingHint",
-									Other: "Ich habe den Artikel, \"{{.Title}}\", gerade erst gelesen. " +
-										"Falls ich nicht Deinen gewünschten Artikel gefunden habe, unterbrich mich und sage: " +
-										"\"Alexa, Suche buchstabieren\", um Deine Suchanfrage zu buchstabieren. Hier ist der Artikel:",
-								},
-								TemplateData: map[string]string{"Title": definition.Title},
-							}) + "\n\n" +
-								strings.TrimRight(h.bodyChopper.FetchBodyPart(definition.Body, 0), ". ") + ". " +
-								l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-									ID: "FurtherNavigationHints",
-									Other: "Zur weiteren Navigation kannst Du jederzeit zum Inhaltsverzeichnis springen" +
-										" indem Du \"Inhaltsverzeichnis\" oder \"nächster Abschnitt\" sagst. " +
-										"Soll ich zunächst einfach weiterlesen?",
-								}})),
-					},
-					SessionAttributes: map[string]interface{}{
-						"word":                         intent.Slots["word"].Value,
-						"position":                     0,
-						"position_within_section_body": 0,
-						"last_question":                "should_continue",
-					},
-				}
-			}
-			logger.Debugw("title was not recently found", "title", definition.Title)
-			h.interactionLogger.Log(alexa.InteractionFrom(requestEnv).WithAttributes(map[string]interface{}{
-				"Intent":      intent.Name,
-				"SearchQuery": intent.Slots["word"].Value,
-				"ActualTitle": definition.Title,
-			}))
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(strings.TrimRight(h.bodyChopper.FetchBodyPart(definition.Body, 0), ". ") + ". " + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID: "FurtherNavigationHints",
-						Other: "Zur weiteren Navigation kannst Du jederzeit zum Inhaltsverzeichnis springen" +
-							" indem Du \"Inhaltsverzeichnis\" oder \"nächster Abschnitt\" sagst. " +
-							"Soll ich zunächst einfach weiterlesen?",
-					}})),
-				},
-				SessionAttributes: map[string]interface{}{
-					"word":                         intent.Slots["word"].Value,
-					"position":                     0,
-					"position_within_section_body": 0,
-					"last_question":                "should_continue",
-				},
-			}
-		case "SpellIntent":
-			if intent.Slots["spelled_term"].Value == "" {
-				// TODO: it's unclear why this ever happens, but according to logs this is what Alexa sometimes sends, especially during certification.
-				//       This could get better error handling or even some slot elicitation (which should have happened automatically), but for now
-				//       this is good enough.
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{
-						OutputSpeech: plainText(
-							l.MustLocalize(&LocalizeConfig{
-								DefaultMessage: &Message{
-									ID:    "CouldNotFindSpelledTerm",
-									Other: "Den buchstabierten Begriff {{.SpelledTerm}} konnte ich bei Wikipedia leider nicht finden. Versuche es doch mit einem anderen Begriff.",
-								},
-								TemplateData: map[string]string{"SpelledTerm": intent.Slots["spelled_term"].Value},
-							}),
-						),
-					},
-				}
-
-			}
-			assembledSearchQuery := l.AssembleTermFromSpelling(intent.Slots["spelled_term"].Value)
-			definition, e := h.findDefinition(assembledSearchQuery, l)
-			if e != nil {
-				logger.Errorw("Could not get Wikipedia page", "error", e)
-				return internalError(l)
-			}
-			if definition == nil {
-				h.interactionLogger.Log(alexa.InteractionFrom(requestEnv).WithAttributes(map[string]interface{}{
-					"Intent":               intent.Name,
-					"SpelledSearchQuery":   intent.Slots["spelled_term"].Value,
-					"AssembledSearchQuery": assembledSearchQuery,
-					"ActualTitle":          "NOT_FOUND",
-				}))
-
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{
-						OutputSpeech: plainText(
-							l.MustLocalize(&LocalizeConfig{
-								DefaultMessage: &Message{
-									ID:    "CouldNotFindSpelledTerm",
-									Other: "Den buchstabierten Begriff {{.SpelledTerm}} konnte ich bei Wikipedia leider nicht finden. Versuche es doch mit einem anderen Begriff.",
-								},
-								TemplateData: map[string]string{"SpelledTerm": intent.Slots["spelled_term"].Value},
-							}),
-						),
-					},
-				}
-			}
-			h.interactionLogger.Log(alexa.InteractionFrom(requestEnv).WithAttributes(map[string]interface{}{
-				"Intent":               intent.Name,
-				"SpelledSearchQuery":   intent.Slots["spelled_term"].Value,
-				"AssembledSearchQuery": assembledSearchQuery,
-				"ActualTitle":          definition.Title,
-			}))
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(strings.TrimRight(h.bodyChopper.FetchBodyPart(definition.Body, 0), ". ") + ". " + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID: "FurtherNavigationHints",
-						Other: "Zur weiteren Navigation kannst Du jederzeit zum Inhaltsverzeichnis springen" +
-							" indem Du \"Inhaltsverzeichnis\" oder \"nächster Abschnitt\" sagst. " +
-							"Soll ich zunächst einfach weiterlesen?",
-					}})),
-				},
-				SessionAttributes: map[string]interface{}{
-					"word":                         assembledSearchQuery,
-					"position":                     0,
-					"position_within_section_body": 0,
-					"last_question":                "should_continue",
-				},
-			}
-		case "AMAZON.YesIntent", "AMAZON.ResumeIntent":
-			if lastQuestionIn(requestEnv.Session) != "should_continue" {
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID:    "What",
-						Other: "Wie meinen?",
-					}}))},
-					SessionAttributes: requestEnv.Session.Attributes,
-				}
-			}
-			page, resp := h.pageFromSession(requestEnv.Session, l, logger)
-			if resp != nil {
-				return resp
-			}
-
-			newPosition, newPositionWithinSectionBody := h.bodyChopper.MoveToNextBodyPart(
-				page.TextForPosition(int(requestEnv.Session.Attributes["position"].(float64))),
-				int(requestEnv.Session.Attributes["position"].(float64)),
-				int(requestEnv.Session.Attributes["position_within_section_body"].(float64)))
-
-			bodyPart := h.bodyChopper.FetchBodyPart(page.TextForPosition(newPosition), newPositionWithinSectionBody)
-
-			if bodyPart == "" {
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{
-						OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-							ID:    "EndOfArticle",
-							Other: "Oh! Wir sind bereits am Ende angelangt. Wenn Du noch einen weiteren Artikel vorgelesen kriegen möchtest, sage z.B. \"Suche nach Elefant\"",
-						}})),
-					},
-					SessionAttributes: map[string]interface{}{
-						"word":                         requestEnv.Session.Attributes["word"],
-						"position":                     newPosition,
-						"position_within_section_body": newPositionWithinSectionBody,
-					},
-				}
-			}
-
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(bodyPart + "\n\n" +
-						l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-							ID:    "ShouldIContinue",
-							Other: "Soll ich noch weiterlesen?",
-						}})),
-				},
-				SessionAttributes: map[string]interface{}{
-					"word":                         requestEnv.Session.Attributes["word"],
-					"position":                     newPosition,
-					"position_within_section_body": newPositionWithinSectionBody,
-					"last_question":                "should_continue",
-				},
-			}
-		case "AMAZON.RepeatIntent":
-			page, resp := h.pageFromSession(requestEnv.Session, l, logger)
-			if resp != nil {
-				return resp
-			}
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(h.bodyChopper.FetchBodyPart(
-						page.TextForPosition(int(requestEnv.Session.Attributes["position"].(float64))),
-						int(requestEnv.Session.Attributes["position_within_section_body"].(float64)),
-					) +
-						"\n\n" + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID:    "ShouldIContinue",
-						Other: "Soll ich noch weiterlesen?",
-					}})),
-				},
-				SessionAttributes: map[string]interface{}{
-					"word":                         requestEnv.Session.Attributes["word"],
-					"position":                     requestEnv.Session.Attributes["position"],
-					"position_within_section_body": requestEnv.Session.Attributes["position_within_section_body"],
-					"last_question":                "should_continue",
-				},
-			}
-		case "AMAZON.NextIntent":
-			page, resp := h.pageFromSession(requestEnv.Session, l, logger)
-			if resp != nil {
-				return resp
-			}
-			newPosition, newPositionWithinSectionBody := h.bodyChopper.MoveToNextBodyPart(
-				page.TextForPosition(int(requestEnv.Session.Attributes["position"].(float64))),
-				int(requestEnv.Session.Attributes["position"].(float64)),
-				int(requestEnv.Session.Attributes["position_within_section_body"].(float64)))
+// Registry gives access to the handlers, e.g. to add handlers for new intents.
+func (h *WikipediaSkill) Registry() *Registry { return h.registry }
 
-			bodyPart := h.bodyChopper.FetchBodyPart(page.TextForPosition(newPosition), newPositionWithinSectionBody)
+// Use appends middleware. It runs within all middlewares added before.
+func (h *WikipediaSkill) Use(middleware Middleware) {
+	h.middlewares = append(h.middlewares, middleware)
+}
 
-			if bodyPart == "" {
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{
-						OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-							ID:    "EndOfArticle",
-							Other: "Oh! wir sind bereits am Ende angelangt. Wenn Du noch einen weiteren Artikel vorgelesen kriegen möchtest, sage z.B. \"Suche nach Elefant\".",
-						}})),
-					},
-					SessionAttributes: map[string]interface{}{
-						"word":                         requestEnv.Session.Attributes["word"],
-						"position":                     newPosition,
-						"position_within_section_body": newPositionWithinSectionBody,
-					},
-				}
-			}
+func (h *WikipediaSkill) ProcessRequest(requestEnv *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
+	logger := h.logger.With("alexa-request-id", requestEnv.Request.RequestID)
+	return Chain(h.registry, h.middlewares...).Handle(&Request{Envelope: requestEnv, Context: context.Background(), Logger: logger})
+}
 
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(bodyPart + "\n\n" +
-						l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-							ID:    "ShouldIContinue",
-							Other: "Soll ich noch weiterlesen?",
-						}})),
-				},
-				SessionAttributes: map[string]interface{}{
-					"word":                         requestEnv.Session.Attributes["word"],
-					"position":                     newPosition,
-					"position_within_section_body": newPositionWithinSectionBody,
-					"last_question":                "should_continue",
-				},
-			}
-		case "AMAZON.NoIntent":
-			if lastQuestionIn(requestEnv.Session) != "should_continue" {
-				return &alexa.ResponseEnvelope{Version: "1.0",
-					Response: &alexa.Response{OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID:    "What",
-						Other: "Wie meinen?",
-					}}))},
-					SessionAttributes: requestEnv.Session.Attributes,
-				}
-			}
-			delete(requestEnv.Session.Attributes, "last_question")
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID:    "No?Okay",
-						Other: "Nein? Okay.",
-					}})),
-					ShouldSessionEnd: true,
-				},
-				SessionAttributes: requestEnv.Session.Attributes,
-			}
-		case "AMAZON.PauseIntent":
-			delete(requestEnv.Session.Attributes, "last_question")
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response:          &alexa.Response{OutputSpeech: plainText(" ")},
-				SessionAttributes: requestEnv.Session.Attributes,
-			}
-		case "TocIntent":
-			page, resp := h.pageFromSession(requestEnv.Session, l, logger)
-			if resp != nil {
-				return resp
-			}
-			requestEnv.Session.Attributes["last_question"] = "jump_where"
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(page.Toc(l) + " " + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID:    "WhichSectionToJump",
-						Other: "Zu welchem Abschnitt möchtest Du springen?",
-					}})),
-				},
-				SessionAttributes: requestEnv.Session.Attributes,
-			}
-		case "GoToSectionIntent":
-			page, resp := h.pageFromSession(requestEnv.Session, l, logger)
-			if resp != nil {
-				return resp
-			}
-			sectionTitleOrNumber := intent.Slots["section_title_or_number"].Value
-			s, position := page.TextAndPositionFromSectionNumber(sectionTitleOrNumber, l)
-			if s == "" {
-				s, position = page.TextAndPositionFromSectionName(sectionTitleOrNumber, l)
-			}
-			s = h.bodyChopper.FetchBodyPart(s, 0)
-			lastQuestion := ""
-			if s != "" {
-				s += "\n\n" + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-					ID:    "ShouldIContinue",
-					Other: "Soll ich noch weiterlesen?",
-				}})
-				lastQuestion = "should_continue"
-			} else {
-				s = l.MustLocalize(&LocalizeConfig{
-					DefaultMessage: &Message{
-						ID:    "CouldNotFindSection",
-						Other: "Ich konnte den angegebenen Abschnitt \"{{.SectionTitleOrNumber}}\" nicht finden.",
-					},
-					TemplateData: map[string]string{"SectionTitleOrNumber": sectionTitleOrNumber},
-				})
-				position = int(requestEnv.Session.Attributes["position"].(float64))
-				lastQuestion = "none"
-			}
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{OutputSpeech: plainText(s)},
-				SessionAttributes: map[string]interface{}{
-					"word":                         requestEnv.Session.Attributes["word"],
-					"position":                     position,
-					"position_within_section_body": 0,
-					"last_question":                lastQuestion,
-				},
-			}
-		case "AMAZON.HelpIntent":
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID: "HelpText",
-						Other: "Um einen Artikel vorgelesen zu bekommen, " +
-							"sage z.B. \"Suche nach Käsekuchen.\" oder \"Was ist Käsekuchen?\". " +
-							"Du kannst jederzeit zum Inhaltsverzeichnis springen, indem Du \"Inhaltsverzeichnis\" sagst. " +
-							"Oder sage \"Springe zu Abschnitt 3.2\", um direkt zu diesem Abschnitt zu springen.",
-					}})),
-				},
-			}
-		case "AMAZON.FallbackIntent":
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{
-					OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
-						ID:    "FallbackText",
-						Other: "Meine Enzyklopädie kann hiermit nicht weiterhelfen. Aber Du kannst z.B. sagen \"Suche nach Käsekuchen\".",
-					}})),
+// launch offers to resume reading where the user left off when the last session timed out.
+func (h *WikipediaSkill) launch(r *Request) *alexa.ResponseEnvelope {
+	userPreferences := h.preferencesFor(r.UserID(), r.Logger)
+	bookmark := userPreferences.Bookmark
+	if bookmark == nil {
+		return welcome(r, SessionState{LastQuestion: DialogStateNone})
+	}
+	userPreferences.Bookmark = nil
+	e := h.userPreferences.Put(r.UserID(), userPreferences)
+	if e != nil {
+		r.Logger.Errorw("Could not remove bookmark", "error", e)
+	}
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{
+				DefaultMessage: &Message{
+					ID:    "OfferToResume",
+					Other: "Willkommen zurück bei Wikipedia. Beim letzten Mal haben wir über \"{{.Word}}\" gelesen. Soll ich dort weiterlesen?",
 				},
-			}
-		case "AMAZON.CancelIntent", "AMAZON.StopIntent":
-			return &alexa.ResponseEnvelope{Version: "1.0",
-				Response: &alexa.Response{ShouldSessionEnd: true},
-			}
-		default:
-			return internalError(l)
-		}
-
-	case "SessionEndedRequest":
-		return &alexa.ResponseEnvelope{Version: "1.0"}
-
-	default:
-		return &alexa.ResponseEnvelope{Version: "1.0"}
+				TemplateData: map[string]string{"Word": bookmark.Word},
+			})),
+		},
+		SessionAttributes: SessionState{
+			Word:                      bookmark.Word,
+			Position:                  bookmark.Position,
+			PositionWithinSectionBody: bookmark.PositionWithinSectionBody,
+			LastQuestion:              DialogStateShouldResume,
+		}.WithPreferences(userPreferences).WithReadingMode(bookmark.ReadingMode).Encode(),
 	}
 }
 
-const recentlyFoundThreshold = 20 * time.Second
+// navigateHome leaves the current article, but keeps the session's preferences.
+func (h *WikipediaSkill) navigateHome(r *Request) *alexa.ResponseEnvelope {
+	return welcome(r, SessionState{LastQuestion: DialogStateNone}.WithPreferences(r.State.Preferences()))
+}
 
-func titleWasAlreadyRecentlyFound(currentTitle string, userInteractions []*alexa.Interaction) bool {
-	for i := len(userInteractions) - 1; i >= 0; i-- {
-		if userInteractions[i].RequestType == "IntentRequest" &&
-			userInteractions[i].Attributes["Intent"] == "DefineIntent" &&
-			userInteractions[i].Attributes["ActualTitle"] == currentTitle &&
-			time.Now().Sub(userInteractions[i].Timestamp) < recentlyFoundThreshold {
+func welcome(r *Request, state SessionState) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(
+				r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+					ID: "YouAreAtWikipediaNow",
+				}})),
+		},
+		SessionAttributes: state.Encode(),
+	}
+}
 
-			return true
-		}
+// notSupported handles built-in intents that don't make sense for articles, e.g. looping or shuffling,
+// and continues where we left off.
+func (h *WikipediaSkill) notSupported(r *Request) *alexa.ResponseEnvelope {
+	s := r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+		ID:    "NotSupportedForArticles",
+		Other: "Das geht bei Wikipedia-Artikeln leider nicht.",
+	}})
+	if r.State.LastQuestion == DialogStateShouldContinue {
+		s += " " + r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "ShouldIContinue",
+			Other: "Soll ich noch weiterlesen?",
+		}})
+	}
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response:          &alexa.Response{OutputSpeech: plainText(s)},
+		SessionAttributes: r.State.Encode(),
 	}
-	return false
 }
 
-func (h *WikipediaSkill) findDefinition(word string, l *locale.Localizer) (*wiki.Page, error) {
-	var (
-		searchResult wiki.Page
-		searchError  error
-		wg           sync.WaitGroup
-	)
-	wg.Add(1)
-	go func() {
-		// TODO: Is this really necessary? Potentially remove.
-		time.Sleep(10 * time.Millisecond) // Attempt to see if it helps when GetPage starts first
-		searchResult, searchError = h.wiki.SearchPage(word, l)
-		wg.Done()
-	}()
-	page, e := h.wiki.GetPage(word, l)
-	switch {
-	case isNotFoundError(e):
-		wg.Wait()
-		switch {
-		case isNotFoundError(searchError):
-			return nil, nil
-		case searchError != nil:
-			return nil, searchError
-		default:
-			return &searchResult, nil
-		}
-	case e != nil:
-		return nil, e
-	default:
-		return &page, nil
+func (h *WikipediaSkill) help(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(r.Localizer.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+				ID: "HelpText",
+				Other: "Um einen Artikel vorgelesen zu bekommen, " +
+					"sage z.B. \"Suche nach Käsekuchen.\" oder \"Was ist Käsekuchen?\". " +
+					"Du kannst jederzeit zum Inhaltsverzeichnis springen, indem Du \"Inhaltsverzeichnis\" sagst. " +
+					"Oder sage \"Springe zu Abschnitt 3.2\", um direkt zu diesem Abschnitt zu springen.",
+			}})),
+		},
 	}
 }
 
-func isNotFoundError(e error) bool {
-	return e != nil && e.Error() == "Page not found on Wikipedia"
+func (h *WikipediaSkill) stop(r *Request) *alexa.ResponseEnvelope {
+	return &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{ShouldSessionEnd: true},
+	}
 }
 
-func lastQuestionIn(session *alexa.Session) string {
-	if session.Attributes["last_question"] == nil {
-		return ""
+// sessionEnded bookmarks the current position when the user didn't answer anymore, so reading can be resumed
+// in the next session.
+func (h *WikipediaSkill) sessionEnded(r *Request) *alexa.ResponseEnvelope {
+	if r.Envelope.Request.Reason != "EXCEEDED_MAX_REPROMPTS" || !r.State.HasWord() {
+		return &alexa.ResponseEnvelope{Version: "1.0"}
 	}
-	return session.Attributes["last_question"].(string)
+	userPreferences := h.preferencesFor(r.UserID(), r.Logger)
+	userPreferences.Bookmark = &preferences.Bookmark{
+		Word:                      r.State.Word,
+		Position:                  r.State.Position,
+		PositionWithinSectionBody: r.State.PositionWithinSectionBody,
+		ReadingMode:               r.State.ReadingMode,
+	}
+	e := h.userPreferences.Put(r.UserID(), userPreferences)
+	if e != nil {
+		r.Logger.Errorw("Could not store bookmark", "error", e)
+	}
+	return &alexa.ResponseEnvelope{Version: "1.0"}
 }
 
-func (h *WikipediaSkill) pageFromSession(session *alexa.Session, l *locale.Localizer, logger *zap.SugaredLogger) (wiki.Page, *alexa.ResponseEnvelope) {
-	if !wordIn(session) {
-		return wiki.Page{}, quickHelp(session.Attributes, l)
+func (h *WikipediaSkill) pageFromSession(r *Request) (wiki.Page, *alexa.ResponseEnvelope) {
+	state, l, logger := &r.State, r.Localizer, r.Logger
+	if !state.HasWord() {
+		return wiki.Page{}, quickHelp(state.Encode(), l)
 	}
 
-	page, e := h.wiki.GetPage(session.Attributes["word"].(string), l)
+	page, e := h.wiki.GetPage(r.Context, state.Word, l)
 	switch {
 	case isNotFoundError(e):
-		page, e = h.wiki.SearchPage(session.Attributes["word"].(string), l)
+		page, e = h.wiki.SearchPage(r.Context, state.Word, l)
 		switch {
 		case isNotFoundError(e):
 			return wiki.Page{}, &alexa.ResponseEnvelope{Version: "1.0",
@@ -552,9 +235,94 @@ func (h *WikipediaSkill) pageFromSession(session *alexa.Session, l *locale.Local
 		logger.Errorw("Could not get Wikipedia page", "error", e)
 		return wiki.Page{}, internalError(l)
 	}
+	if state.ReadingMode == preferences.ReadingModeSummary {
+		page = page.Summary()
+	}
+	*state = state.withinPage(page)
 	return page, nil
 }
 
+func (h *WikipediaSkill) goToSection(r *Request, page wiki.Page, sectionTitleOrNumber string) *alexa.ResponseEnvelope {
+	state, l := r.State, r.Localizer
+	s, position := page.TextAndPositionFromSectionNumber(sectionTitleOrNumber, l)
+	if s == "" {
+		s, position = page.TextAndPositionFromSectionName(sectionTitleOrNumber, l)
+	}
+	s = newBodyChopper(state.MaxBodyPartLen, h.countChopperFallback).FetchBodyPart(s, 0)
+	var lastQuestion DialogState
+	if s != "" {
+		s += "\n\n" + l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+			ID:    "ShouldIContinue",
+			Other: "Soll ich noch weiterlesen?",
+		}})
+		lastQuestion = DialogStateShouldContinue
+	} else {
+		s = l.MustLocalize(&LocalizeConfig{
+			DefaultMessage: &Message{
+				ID:    "CouldNotFindSection",
+				Other: "Ich konnte den angegebenen Abschnitt \"{{.SectionTitleOrNumber}}\" nicht finden.",
+			},
+			TemplateData: map[string]string{"SectionTitleOrNumber": sectionTitleOrNumber},
+		})
+		position = state.Position
+		lastQuestion = DialogStateNone
+	}
+	return withArticleRendering(r.Envelope, page, &alexa.ResponseEnvelope{Version: "1.0",
+		Response:          &alexa.Response{OutputSpeech: plainText(s)},
+		SessionAttributes: state.AtPosition(position, 0).WithLastQuestion(lastQuestion).Encode(),
+	}, l)
+}
+
+// withArticleRendering attaches a visual representation of the article to responses that read a body part,
+// provided the device has a screen.
+func withArticleRendering(requestEnv *alexa.RequestEnvelope, page wiki.Page, response *alexa.ResponseEnvelope, l *locale.Localizer) *alexa.ResponseEnvelope {
+	if !apl.IsSupportedBy(requestEnv) {
+		return response
+	}
+	state := DecodeSessionState(response.SessionAttributes)
+	if !state.HasWord() {
+		return response
+	}
+	bodyPart := newBodyChopper(state.MaxBodyPartLen, nil).FetchBodyPart(page.TextForPosition(state.Position), state.PositionWithinSectionBody)
+	if bodyPart == "" {
+		return response
+	}
+	response.Response.Directives = append(response.Response.Directives, apl.RenderArticle(page, state.Position, state.PositionWithinSectionBody, bodyPart,
+		l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{ID: "TableOfContents", Other: "Inhaltsverzeichnis"}})))
+	return response
+}
+
+// bodyPartResponse reads the body part at the given position and asks whether to continue.
+// An optional intro is spoken before the body part.
+func (h *WikipediaSkill) bodyPartResponse(page wiki.Page, r *Request, position int, positionWithinSectionBody int, intro string) *alexa.ResponseEnvelope {
+	state, l := r.State, r.Localizer
+	bodyPart := newBodyChopper(state.MaxBodyPartLen, h.countChopperFallback).FetchBodyPart(page.TextForPosition(position), positionWithinSectionBody)
+	if bodyPart == "" {
+		return &alexa.ResponseEnvelope{Version: "1.0",
+			Response: &alexa.Response{
+				OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+					ID:    "EndOfArticle",
+					Other: "Oh! Wir sind bereits am Ende angelangt. Wenn Du noch einen weiteren Artikel vorgelesen kriegen möchtest, sage z.B. \"Suche nach Elefant\"",
+				}})),
+			},
+			SessionAttributes: state.AtPosition(position, positionWithinSectionBody).WithLastQuestion(DialogStateNone).Encode(),
+		}
+	}
+	if intro != "" {
+		bodyPart = intro + "\n\n" + bodyPart
+	}
+	return withArticleRendering(r.Envelope, page, &alexa.ResponseEnvelope{Version: "1.0",
+		Response: &alexa.Response{
+			OutputSpeech: plainText(bodyPart + "\n\n" +
+				l.MustLocalize(&LocalizeConfig{DefaultMessage: &Message{
+					ID:    "ShouldIContinue",
+					Other: "Soll ich noch weiterlesen?",
+				}})),
+		},
+		SessionAttributes: state.AtPosition(position, positionWithinSectionBody).WithLastQuestion(DialogStateShouldContinue).Encode(),
+	}, l)
+}
+
 func quickHelp(sessionAttributes map[string]interface{}, l *locale.Localizer) *alexa.ResponseEnvelope {
 	return &alexa.ResponseEnvelope{Version: "1.0",
 		Response: &alexa.Response{OutputSpeech: plainText(l.MustLocalize(&LocalizeConfig{
@@ -568,10 +336,6 @@ func quickHelp(sessionAttributes map[string]interface{}, l *locale.Localizer) *a
 	}
 }
 
-func wordIn(session *alexa.Session) bool {
-	return session.Attributes["word"] != nil
-}
-
 func plainText(text string) *alexa.OutputSpeech {
 	return &alexa.OutputSpeech{Type: "PlainText", Text: text}
 }
@@ -587,3 +351,61 @@ func internalError(l *locale.Localizer) *alexa.ResponseEnvelope {
 		},
 	}
 }
+
+func (h *WikipediaSkill) preferencesFor(userID string, logger *zap.SugaredLogger) preferences.Preferences {
+	userPreferences, e := h.userPreferences.Get(userID)
+	if e != nil {
+		logger.Errorw("Could not get user preferences", "error", e)
+		return preferences.Preferences{}
+	}
+	return userPreferences
+}
+
+const defaultMaxBodyPartLen = 6000
+
+// bodyPartLens are the body part lengths users can choose from. Alexa doesn't allow much more than 6000 characters
+// of speech in a single response.
+var bodyPartLens = []int{1000, 2000, 4000, defaultMaxBodyPartLen}
+
+// newBodyChopper chops at paragraphs if possible. onFetchFallback, if not nil, is called when a paragraph is too long.
+func newBodyChopper(maxBodyPartLen int, onFetchFallback func()) wiki.BodyChopper {
+	if maxBodyPartLen <= 0 {
+		maxBodyPartLen = defaultMaxBodyPartLen
+	}
+	return &paragraph.BodyChopper{
+		MaxBodyPartLen: maxBodyPartLen,
+		Fallback: dumb.BodyChopper{
+			MaxBodyPartLen: maxBodyPartLen,
+		},
+		OnFetchFallback: onFetchFallback,
+	}
+}
+
+// countChopperFallback is only used where body parts are read out, so each body part is counted once.
+func (h *WikipediaSkill) countChopperFallback() {
+	h.metrics.Count("chopper_fallbacks", nil)
+}
+
+func shorterBodyPartLen(maxBodyPartLen int) int {
+	if maxBodyPartLen <= 0 {
+		maxBodyPartLen = defaultMaxBodyPartLen
+	}
+	for i := len(bodyPartLens) - 1; i >= 0; i-- {
+		if bodyPartLens[i] < maxBodyPartLen {
+			return bodyPartLens[i]
+		}
+	}
+	return bodyPartLens[0]
+}
+
+func longerBodyPartLen(maxBodyPartLen int) int {
+	if maxBodyPartLen <= 0 {
+		maxBodyPartLen = defaultMaxBodyPartLen
+	}
+	for _, bodyPartLen := range bodyPartLens {
+		if bodyPartLen > maxBodyPartLen {
+			return bodyPartLen
+		}
+	}
+	return bodyPartLens[len(bodyPartLens)-1]
+}
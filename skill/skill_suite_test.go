@@ -0,0 +1,27 @@
+package skill_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/text/language"
+
+	"github.com/petergtz/alexa-wikipedia/locale"
+)
+
+func TestSkill(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Skill Suite")
+}
+
+func newI18nBundle() *i18n.Bundle {
+	i18nBundle := i18n.NewBundle(language.English)
+	i18nBundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
+	i18nBundle.MustParseMessageFileBytes(locale.DeDe, "active.de.toml")
+	i18nBundle.MustParseMessageFileBytes(locale.EnUs, "active.en.toml")
+	i18nBundle.MustParseMessageFileBytes(locale.EsEs, "active.es.toml")
+	return i18nBundle
+}
@@ -0,0 +1,114 @@
+// Package skillserver serves a skill over HTTP(S), as an alternative to running it on AWS Lambda.
+package skillserver
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/petergtz/alexa-wikipedia/apl"
+	"github.com/petergtz/alexa-wikipedia/redaction"
+	"github.com/petergtz/go-alexa"
+	"go.uber.org/zap"
+)
+
+// MaxTimestampDeviation is how far the timestamp of a request may be off, as required by Alexa.
+const MaxTimestampDeviation = 150 * time.Second
+
+// Handler works like go-alexa's alexa.Handler, but decodes requests using apl.DecodeRequestEnvelope and allows to
+// switch off signature and timestamp verification independently, e.g. for local use.
+type Handler struct {
+	Skill  alexa.Skill
+	Logger *zap.SugaredLogger
+	// Redactor hashes the IDs and redacts the session attributes in the logged requests and responses.
+	Redactor *redaction.Redactor
+	// ExpectedApplicationID is the skill ID requests must have. Empty means any.
+	ExpectedApplicationID     string
+	SkipSignatureVerification bool
+	SkipTimestampVerification bool
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.SkipSignatureVerification && !alexa.IsValidAlexaRequest(w, req) {
+		h.Logger.Infow("Invalid request signature", "remote-addr", req.RemoteAddr)
+		return
+	}
+
+	requestBody, e := io.ReadAll(req.Body)
+	if e != nil {
+		h.Logger.Errorw("Error while reading request body", "error", e)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var requestEnv alexa.RequestEnvelope
+	e = apl.DecodeRequestEnvelope(requestBody, &requestEnv)
+	if e != nil {
+		h.Logger.Infow("Error while decoding request body", "error", e)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if requestEnv.Request == nil || requestEnv.Session == nil {
+		http.Error(w, "Request or session is empty", http.StatusBadRequest)
+		return
+	}
+	if h.ExpectedApplicationID != "" && requestEnv.Session.Application.ApplicationID != h.ExpectedApplicationID {
+		h.Logger.Infow("ApplicationID does not match", "application-id", requestEnv.Session.Application.ApplicationID)
+		http.Error(w, "Invalid ApplicationID", http.StatusBadRequest)
+		return
+	}
+	if !h.SkipTimestampVerification {
+		timestamp, e := time.Parse(time.RFC3339, requestEnv.Request.Timestamp)
+		if e != nil {
+			h.Logger.Infow("Invalid timestamp", "timestamp", requestEnv.Request.Timestamp)
+			http.Error(w, "Invalid Timestamp", http.StatusBadRequest)
+			return
+		}
+		if deviation := h.now().Sub(timestamp); math.Abs(deviation.Seconds()) > MaxTimestampDeviation.Seconds() {
+			h.Logger.Infow("Timestamp not within time limit", "timestamp", requestEnv.Request.Timestamp, "deviation", deviation.String())
+			http.Error(w, "Timestamp not within time limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.Logger.Infow("Alexa Request",
+		"alexa-request-id", requestEnv.Request.RequestID,
+		"user-id", h.Redactor.Hash(requestEnv.Session.User.UserID),
+		"session-id", h.Redactor.Hash(requestEnv.Session.SessionID),
+		"locale", requestEnv.Request.Locale,
+		"type", requestEnv.Request.Type,
+		"intent", requestEnv.Request.Intent.Name,
+		"session-attributes", h.Redactor.Value(requestEnv.Session.Attributes),
+	)
+
+	response := h.Skill.ProcessRequest(&requestEnv)
+
+	h.Logger.Infow("Alexa Response",
+		"alexa-request-id", requestEnv.Request.RequestID,
+		"response", h.Redactor.Value(response.Response),
+		"session-attributes", h.Redactor.Value(response.SessionAttributes),
+	)
+
+	output, e := json.Marshal(response)
+	if e != nil {
+		h.Logger.Errorw("Error while marshalling response", "error", e)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Write(output)
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now == nil {
+		return time.Now()
+	}
+	return h.Now()
+}
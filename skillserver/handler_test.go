@@ -0,0 +1,145 @@
+package skillserver_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/petergtz/alexa-wikipedia/apl"
+	"github.com/petergtz/alexa-wikipedia/redaction"
+	"github.com/petergtz/alexa-wikipedia/skillserver"
+	"github.com/petergtz/go-alexa"
+)
+
+type recordingSkill struct {
+	requests []*alexa.RequestEnvelope
+	text     string
+}
+
+func (s *recordingSkill) ProcessRequest(requestEnv *alexa.RequestEnvelope) *alexa.ResponseEnvelope {
+	s.requests = append(s.requests, requestEnv)
+	text := s.text
+	if text == "" {
+		text = "Hallo"
+	}
+	return &alexa.ResponseEnvelope{Version: "1.0", Response: &alexa.Response{OutputSpeech: &alexa.OutputSpeech{Type: "PlainText", Text: text}}}
+}
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func post(handler http.Handler, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return recorder
+}
+
+func requestBody(applicationID string, timestamp time.Time) string {
+	return `{
+		"session": {"application": {"applicationId": "` + applicationID + `"}, "user": {"userId": "some-user"}},
+		"request": {"type": "Alexa.Presentation.APL.UserEvent", "timestamp": "` + timestamp.Format(time.RFC3339) + `", "arguments": ["GoToSection", 3]}
+	}`
+}
+
+var _ = Describe("Handler", func() {
+	var (
+		skill   *recordingSkill
+		handler *skillserver.Handler
+	)
+
+	BeforeEach(func() {
+		skill = &recordingSkill{}
+		handler = &skillserver.Handler{
+			Skill:                     skill,
+			Logger:                    zap.NewNop().Sugar(),
+			Redactor:                  redaction.NewRedactor([]byte("secret")),
+			ExpectedApplicationID:     "my-skill",
+			SkipSignatureVerification: true,
+			Now:                       func() time.Time { return now },
+		}
+	})
+
+	It("responds with the skill's response and keeps the arguments of UserEvents", func() {
+		recorder := post(handler, requestBody("my-skill", now.Add(-10*time.Second)))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("application/json"))
+		var response alexa.ResponseEnvelope
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		Expect(response.Response.OutputSpeech.Text).To(Equal("Hallo"))
+		Expect(skill.requests).To(HaveLen(1))
+		Expect(apl.Arguments(skill.requests[0].Request)).To(Equal([]string{"GoToSection", "3"}))
+	})
+
+	It("logs requests with hashed IDs", func() {
+		core, logs := observer.New(zap.InfoLevel)
+		handler.Logger = zap.New(core).Sugar()
+
+		post(handler, requestBody("my-skill", now))
+
+		requestLogs := logs.FilterMessage("Alexa Request").All()
+		Expect(requestLogs).To(HaveLen(1))
+		Expect(requestLogs[0].ContextMap()).To(HaveKeyWithValue("user-id", handler.Redactor.Hash("some-user")))
+		Expect(requestLogs[0].ContextMap()["user-id"]).NotTo(Equal("some-user"))
+	})
+
+	It("logs only the name of intents and redacts responses", func() {
+		core, logs := observer.New(zap.InfoLevel)
+		handler.Logger = zap.New(core).Sugar()
+		skill.text = "Schreib an jane@example.com"
+
+		post(handler, `{
+			"session": {"application": {"applicationId": "my-skill"}, "user": {"userId": "some-user"}},
+			"request": {"type": "IntentRequest", "timestamp": "`+now.Format(time.RFC3339)+`",
+				"intent": {"name": "DefineIntent", "slots": {"word": {"name": "word", "value": "Jane Doe"}}}}
+		}`)
+
+		requestLogs := logs.FilterMessage("Alexa Request").All()
+		Expect(requestLogs).To(HaveLen(1))
+		Expect(requestLogs[0].ContextMap()).To(HaveKeyWithValue("intent", "DefineIntent"))
+		responseLogs := logs.FilterMessage("Alexa Response").All()
+		Expect(responseLogs).To(HaveLen(1))
+		Expect(fmt.Sprint(responseLogs[0].ContextMap()["response"])).To(ContainSubstring(redaction.Email))
+		Expect(fmt.Sprint(responseLogs[0].ContextMap()["response"])).NotTo(ContainSubstring("jane@example.com"))
+	})
+
+	It("rejects requests for other skills", func() {
+		Expect(post(handler, requestBody("other-skill", now)).Code).To(Equal(http.StatusBadRequest))
+		Expect(skill.requests).To(BeEmpty())
+	})
+
+	It("rejects requests with outdated timestamps unless timestamp verification is skipped", func() {
+		Expect(post(handler, requestBody("my-skill", now.Add(-151*time.Second))).Code).To(Equal(http.StatusBadRequest))
+		Expect(skill.requests).To(BeEmpty())
+
+		handler.SkipTimestampVerification = true
+		Expect(post(handler, requestBody("my-skill", now.Add(-151*time.Second))).Code).To(Equal(http.StatusOK))
+		Expect(skill.requests).To(HaveLen(1))
+	})
+
+	It("rejects unsigned requests unless signature verification is skipped", func() {
+		handler.SkipSignatureVerification = false
+
+		Expect(post(handler, requestBody("my-skill", now)).Code).To(Equal(http.StatusUnauthorized))
+		Expect(skill.requests).To(BeEmpty())
+	})
+
+	It("rejects malformed requests", func() {
+		Expect(post(handler, `{`).Code).To(Equal(http.StatusBadRequest))
+		Expect(post(handler, `{}`).Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("only accepts POST requests", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
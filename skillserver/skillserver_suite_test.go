@@ -0,0 +1,13 @@
+package skillserver_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSkillserver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Skillserver Suite")
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// JSONExporter writes each span as JSON document on a line of its own, e.g. to stdout.
+type JSONExporter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func NewJSONExporter(writer io.Writer) *JSONExporter {
+	return &JSONExporter{writer: writer}
+}
+
+func (e *JSONExporter) Export(span SpanData) {
+	buf, marshalError := json.Marshal(span)
+	if marshalError != nil {
+		buf, _ = json.Marshal(SpanData{TraceID: span.TraceID, SpanID: span.SpanID, ParentSpanID: span.ParentSpanID,
+			Name: span.Name, StartTime: span.StartTime, EndTime: span.EndTime, Error: marshalError.Error()})
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.writer.Write(append(buf, '\n'))
+}
+
+// InMemoryExporter keeps all spans, e.g. to inspect them in tests.
+type InMemoryExporter struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+func (e *InMemoryExporter) Export(span SpanData) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the spans in the order they ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
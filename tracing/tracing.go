@@ -0,0 +1,132 @@
+// Package tracing records spans, i.e. timed and named operations nested into each other, similar to OpenTelemetry.
+// Spans are passed on in a context.Context, so wherever a context reaches, child spans end up in the same trace.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// SpanData is what exporters get when a span ends.
+type SpanData struct {
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Name         string                 `json:"name"`
+	StartTime    time.Time              `json:"start_time"`
+	EndTime      time.Time              `json:"end_time"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+func (d SpanData) Duration() time.Duration { return d.EndTime.Sub(d.StartTime) }
+
+// Exporter gets every span when it ends. It must be safe for concurrent use.
+type Exporter interface {
+	Export(span SpanData)
+}
+
+// Span is an operation in progress. A nil *Span is valid and records nothing, so code can be traced unconditionally.
+// A Span must only be used by one goroutine.
+type Span struct {
+	data   SpanData
+	tracer *Tracer
+	ended  bool
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]interface{})
+	}
+	s.data.Attributes[key] = value
+}
+
+// RecordError marks the span as failed, unless e is nil.
+func (s *Span) RecordError(e error) {
+	if s == nil || e == nil {
+		return
+	}
+	s.data.Error = e.Error()
+}
+
+// End ends the span and exports it. Only the first call has an effect.
+func (s *Span) End() {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	s.data.EndTime = s.tracer.now()
+	s.tracer.exporter.Export(s.data)
+}
+
+// Tracer starts traces. A nil *Tracer starts no spans.
+type Tracer struct {
+	exporter Exporter
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+type spanKey struct{}
+
+// Start starts a span as child of the span in ctx, or a new trace if there is none.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer: t,
+		data:   SpanData{SpanID: newID(8), Name: name, StartTime: t.now()},
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = newID(16)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Start starts a span as child of the span in ctx. Without a span in ctx, there is nothing to trace and it returns a
+// nil *Span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		var noTracer *Tracer
+		return noTracer.Start(ctx, name)
+	}
+	return parent.tracer.Start(ctx, name)
+}
+
+// FromContext returns the span in ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func (t *Tracer) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+	return t.Now()
+}
+
+func newID(bytes int) string {
+	id := make([]byte, bytes)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
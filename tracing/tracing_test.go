@@ -0,0 +1,103 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/petergtz/alexa-wikipedia/tracing"
+)
+
+var _ = Describe("Tracer", func() {
+	var (
+		exporter *tracing.InMemoryExporter
+		tracer   *tracing.Tracer
+		now      time.Time
+	)
+
+	BeforeEach(func() {
+		exporter = &tracing.InMemoryExporter{}
+		tracer = tracing.NewTracer(exporter)
+		now = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		tracer.Now = func() time.Time {
+			now = now.Add(time.Millisecond)
+			return now
+		}
+	})
+
+	It("nests spans started from the context of another span", func() {
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := tracing.Start(ctx, "child")
+		child.SetAttribute("word", "Baum")
+		child.End()
+		root.End()
+
+		spans := exporter.Spans()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name).To(Equal("child"))
+		Expect(spans[0].TraceID).To(Equal(spans[1].TraceID))
+		Expect(spans[0].ParentSpanID).To(Equal(spans[1].SpanID))
+		Expect(spans[0].Attributes).To(Equal(map[string]interface{}{"word": "Baum"}))
+		Expect(spans[0].Duration()).To(Equal(time.Millisecond))
+		Expect(spans[1].Name).To(Equal("root"))
+		Expect(spans[1].ParentSpanID).To(BeEmpty())
+		Expect(spans[1].Duration()).To(Equal(3 * time.Millisecond))
+	})
+
+	It("starts a new trace for each span without parent", func() {
+		_, first := tracer.Start(context.Background(), "first")
+		_, second := tracer.Start(nil, "second")
+		first.End()
+		second.End()
+
+		spans := exporter.Spans()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].TraceID).NotTo(Equal(spans[1].TraceID))
+	})
+
+	It("exports a span only once and records errors", func() {
+		_, span := tracer.Start(context.Background(), "span")
+		span.RecordError(nil)
+		span.RecordError(errors.New("not found"))
+		span.End()
+		span.End()
+
+		Expect(exporter.Spans()).To(HaveLen(1))
+		Expect(exporter.Spans()[0].Error).To(Equal("not found"))
+	})
+
+	It("traces nothing without a tracer", func() {
+		var noTracer *tracing.Tracer
+		ctx, span := noTracer.Start(context.Background(), "root")
+		Expect(span).To(BeNil())
+		span.SetAttribute("word", "Baum")
+		span.RecordError(errors.New("not found"))
+		span.End()
+
+		_, child := tracing.Start(ctx, "child")
+		Expect(child).To(BeNil())
+		_, child = tracing.Start(nil, "child")
+		Expect(child).To(BeNil())
+	})
+})
+
+var _ = Describe("JSONExporter", func() {
+	It("writes one line per span", func() {
+		buffer := &bytes.Buffer{}
+		exporter := tracing.NewJSONExporter(buffer)
+		start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		exporter.Export(tracing.SpanData{TraceID: "t", SpanID: "s1", Name: "root", StartTime: start, EndTime: start.Add(time.Second)})
+		exporter.Export(tracing.SpanData{TraceID: "t", SpanID: "s2", ParentSpanID: "s1", Name: "child", StartTime: start, EndTime: start,
+			Attributes: map[string]interface{}{"host": "de.wikipedia.org"}, Error: "timeout"})
+
+		lines := bytes.Split(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), []byte("\n"))
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(MatchJSON(`{"trace_id":"t","span_id":"s1","name":"root","start_time":"2020-01-01T12:00:00Z","end_time":"2020-01-01T12:00:01Z"}`))
+		Expect(lines[1]).To(MatchJSON(`{"trace_id":"t","span_id":"s2","parent_span_id":"s1","name":"child","start_time":"2020-01-01T12:00:00Z","end_time":"2020-01-01T12:00:00Z","attributes":{"host":"de.wikipedia.org"},"error":"timeout"}`))
+	})
+})
@@ -1,7 +1,9 @@
 package wiki
 
 import (
+	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -16,12 +18,14 @@ type Section struct {
 	Title       string
 	Body        string
 	Subsections []Section
+	// ImageURL is only set for the page itself and points to its lead image, if there is one.
+	ImageURL string
 	// Locale      string
 }
 
 type Wiki interface {
-	GetPage(url string, localizer *locale.Localizer) (Page, error)
-	SearchPage(url string, localizer *locale.Localizer) (Page, error)
+	GetPage(ctx context.Context, url string, localizer *locale.Localizer) (Page, error)
+	SearchPage(ctx context.Context, url string, localizer *locale.Localizer) (Page, error)
 }
 
 func (p Page) TextForPosition(position int) string {
@@ -141,7 +145,196 @@ func (p Page) Toc(localizer *locale.Localizer) string {
 	return s
 }
 
+// SectionForPosition returns the number in digits, e.g. "3.1", and the path of titles, from the top-level section down,
+// of the section read at position. For the lead section of the page, number is empty and titlePath has no elements.
+func (p Page) SectionForPosition(position int) (number string, titlePath []string) {
+	number, titlePath, _, _ = sectionForPosition(Section(p), 0, position, "", nil)
+	return
+}
+
+func sectionForPosition(s Section, cur int, target int, numberDigits string, path []string) (number string, titlePath []string, found bool, new_cur int) {
+	if s.Body != "" && cur == target {
+		return numberDigits, path, true, cur
+	}
+	if s.Body != "" {
+		cur++
+	}
+	for i, section := range s.Subsections {
+		sectionNumberDigits := strconv.Itoa(i + 1)
+		if numberDigits != "" {
+			sectionNumberDigits = numberDigits + "." + sectionNumberDigits
+		}
+		number, titlePath, found, cur = sectionForPosition(section, cur, target, sectionNumberDigits, append(path[:len(path):len(path)], section.Title))
+		if found {
+			return number, titlePath, true, cur
+		}
+	}
+	return "", nil, false, cur
+}
+
+// PercentageRead estimates how far into the page the given position is, based on the text length.
+func (p Page) PercentageRead(position int, positionWithinSectionBody int) int {
+	read, total, cur := positionWithinSectionBody, 0, 0
+	visitTextLengths(Section(p), 0, func(textLen int) {
+		if cur < position {
+			read += textLen
+		}
+		total += textLen
+		cur++
+	})
+	if total == 0 || read >= total {
+		return 100
+	}
+	return read * 100 / total
+}
+
+// visitTextLengths calls visit with len(p.TextForPosition(position)) for every position in order.
+func visitTextLengths(s Section, prefixLen int, visit func(textLen int)) {
+	if s.Body != "" {
+		visit(prefixLen + len(s.Title) + len(". ") + len(s.Body))
+	}
+	for i, section := range s.Subsections {
+		if i == 0 && s.Body == "" {
+			visitTextLengths(section, prefixLen+len(s.Title)+len(". "), visit)
+		} else {
+			visitTextLengths(section, 0, visit)
+		}
+	}
+}
+
+// NumPositions returns the number of readable positions, i.e. sections with a non-empty body, in the page.
+// Positions range from 0 to NumPositions()-1.
+func (p Page) NumPositions() int {
+	return numPositions(Section(p))
+}
+
+func numPositions(s Section) int {
+	n := 0
+	if s.Body != "" {
+		n++
+	}
+	for _, section := range s.Subsections {
+		n += numPositions(section)
+	}
+	return n
+}
+
+// PositionOfNextTopLevelSection returns the position at which the top-level section following the one containing
+// position starts. When position is already within the last top-level section, it returns NumPositions(),
+// i.e. the end of the page.
+func (p Page) PositionOfNextTopLevelSection(position int) int {
+	cur := 0
+	if p.Body != "" {
+		cur++
+	}
+	for _, section := range p.Subsections {
+		if cur > position {
+			return cur
+		}
+		cur += numPositions(section)
+	}
+	return cur
+}
+
+// MoveToPreviousBodyPart moves backwards by one body part. When the current position is at the beginning of a section
+// body, it moves to the last body part of the preceding section. At the very beginning of the page it stays there.
+func (p Page) MoveToPreviousBodyPart(bodyChopper BodyChopper, currentPosition int, currentPositionWithinSectionBody int) (newPosition int, newPositionWithinSectionBody int) {
+	if currentPositionWithinSectionBody == 0 {
+		if currentPosition == 0 {
+			return 0, 0
+		}
+		currentPosition--
+		currentPositionWithinSectionBody = len(p.TextForPosition(currentPosition))
+	}
+	return bodyChopper.MoveToPreviousBodyPart(p.TextForPosition(currentPosition), currentPosition, currentPositionWithinSectionBody)
+}
+
+// Summary returns a condensed view of the page consisting of the lead section and the first sentence of each
+// top-level section. The view has its own positions, which can be mapped back to the page via the top-level sections.
+func (p Page) Summary() Page {
+	summary := Page{
+		Number:      p.Number,
+		Title:       p.Title,
+		Body:        p.Body,
+		Subsections: make([]Section, len(p.Subsections)),
+		ImageURL:    p.ImageURL,
+	}
+	for i, section := range p.Subsections {
+		summary.Subsections[i] = Section{
+			Number: section.Number,
+			Title:  section.Title,
+			Body:   firstSentence(firstBody(section)),
+		}
+	}
+	return summary
+}
+
+func firstBody(s Section) string {
+	if s.Body != "" {
+		return s.Body
+	}
+	for _, section := range s.Subsections {
+		if body := firstBody(section); body != "" {
+			return body
+		}
+	}
+	return ""
+}
+
+var sentenceEndPattern = regexp.MustCompile(`[.!?](\s|$)|\n`)
+
+func firstSentence(text string) string {
+	text = strings.TrimSpace(text)
+	index := sentenceEndPattern.FindStringIndex(text)
+	if index == nil {
+		return text
+	}
+	return strings.TrimSpace(text[:index[0]+1])
+}
+
+// TopLevelSectionForPosition returns the index of the top-level section containing position,
+// or -1 if position is within the lead section.
+func (p Page) TopLevelSectionForPosition(position int) int {
+	cur := 0
+	if p.Body != "" {
+		cur++
+	}
+	if position < cur {
+		return -1
+	}
+	for i, section := range p.Subsections {
+		cur += numPositions(section)
+		if position < cur {
+			return i
+		}
+	}
+	return len(p.Subsections) - 1
+}
+
+// PositionOfTopLevelSection returns the position at which the top-level section with the given index starts.
+// An index of -1 refers to the lead section.
+func (p Page) PositionOfTopLevelSection(index int) int {
+	if index < 0 {
+		return 0
+	}
+	if index > len(p.Subsections) {
+		index = len(p.Subsections)
+	}
+	cur := 0
+	if p.Body != "" {
+		cur++
+	}
+	for _, section := range p.Subsections[:index] {
+		cur += numPositions(section)
+	}
+	return cur
+}
+
 type BodyChopper interface {
 	MoveToNextBodyPart(body string, currentPosition int, currentPositionWithinSectionBody int) (newPosition int, newPositionWithinSectionBody int)
+	// MoveToPreviousBodyPart returns the start of the body part that precedes currentPositionWithinSectionBody in body.
+	// currentPositionWithinSectionBody may be len(body) to get the start of the last body part. When it is 0, there is no
+	// previous body part within body and the result is the beginning of the previous position.
+	MoveToPreviousBodyPart(body string, currentPosition int, currentPositionWithinSectionBody int) (newPosition int, newPositionWithinSectionBody int)
 	FetchBodyPart(body string, currentPositionWithinSectionBody int) string
 }
@@ -8,6 +8,7 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/text/language"
 
+	"github.com/petergtz/alexa-wikipedia/bodychoppers/dumb"
 	"github.com/petergtz/alexa-wikipedia/locale"
 	"github.com/petergtz/alexa-wikipedia/wiki"
 )
@@ -121,6 +122,100 @@ var _ = Describe("Wiki", func() {
 			text, _ := page.TextAndPositionFromSectionNumber("drei punkt zwei", localizer)
 			Expect(text).To(Equal("Abschnitt drei.zwei. C.B. Body C.B"))
 		})
+
+		It("maps positions back to sections", func() {
+			number, titlePath := page.SectionForPosition(0)
+			Expect(number).To(BeEmpty())
+			Expect(titlePath).To(BeEmpty())
+
+			number, titlePath = page.SectionForPosition(2)
+			Expect(number).To(Equal("2"))
+			Expect(titlePath).To(Equal([]string{"B"}))
+
+			number, titlePath = page.SectionForPosition(3)
+			Expect(number).To(Equal("3.1"))
+			Expect(titlePath).To(Equal([]string{"C", "C.A"}))
+
+			number, titlePath = page.SectionForPosition(5)
+			Expect(number).To(Equal("3.1.2"))
+			Expect(titlePath).To(Equal([]string{"C", "C.A", "C.A.B"}))
+		})
+
+		It("estimates the percentage read", func() {
+			Expect(page.PercentageRead(0, 0)).To(Equal(0))
+			Expect(page.PercentageRead(4, 0)).To(BeNumerically("~", 40, 10))
+			Expect(page.PercentageRead(8, 0)).To(Equal(100))
+		})
+
+		It("bases the percentage read on the text of the positions", func() {
+			read, total := 0, 0
+			for i := 0; i < page.NumPositions(); i++ {
+				if i < 5 {
+					read += len(page.TextForPosition(i))
+				}
+				total += len(page.TextForPosition(i))
+			}
+			Expect(page.PercentageRead(5, 3)).To(Equal((read + 3) * 100 / total))
+		})
+
+		It("counts all positions", func() {
+			Expect(page.NumPositions()).To(Equal(8))
+		})
+
+		It("finds the next top-level section", func() {
+			Expect(page.PositionOfNextTopLevelSection(0)).To(Equal(1))
+			Expect(page.PositionOfNextTopLevelSection(1)).To(Equal(2))
+			Expect(page.PositionOfNextTopLevelSection(2)).To(Equal(3))
+			Expect(page.PositionOfNextTopLevelSection(5)).To(Equal(8))
+			Expect(page.TextForPosition(page.PositionOfNextTopLevelSection(5))).To(BeEmpty())
+		})
+
+		Describe("Summary", func() {
+			It("consists of the lead and the first sentence of each top-level section", func() {
+				page.Subsections[0].Body = "First sentence of A. Second sentence of A."
+				summary := page.Summary()
+
+				Expect(summary.NumPositions()).To(Equal(4))
+				Expect(summary.TextForPosition(0)).To(Equal("Main Title. Intro"))
+				Expect(summary.TextForPosition(1)).To(Equal("A. First sentence of A."))
+				Expect(summary.TextForPosition(2)).To(Equal("B. Body B"))
+				Expect(summary.TextForPosition(3)).To(Equal("C. Body C.A"))
+			})
+
+			It("maps summary positions back to the page", func() {
+				summary := page.Summary()
+
+				Expect(page.PositionOfTopLevelSection(summary.TopLevelSectionForPosition(0))).To(Equal(0))
+				Expect(page.PositionOfTopLevelSection(summary.TopLevelSectionForPosition(2))).To(Equal(2))
+				Expect(page.PositionOfTopLevelSection(summary.TopLevelSectionForPosition(3))).To(Equal(3))
+				Expect(page.TopLevelSectionForPosition(6)).To(Equal(2))
+			})
+		})
+
+		Describe("MoveToPreviousBodyPart", func() {
+			var bodyChopper wiki.BodyChopper
+
+			BeforeEach(func() { bodyChopper = dumb.BodyChopper{MaxBodyPartLen: 5} })
+
+			It("stays at the beginning of the page", func() {
+				position, positionWithinSectionBody := page.MoveToPreviousBodyPart(bodyChopper, 0, 0)
+				Expect(position).To(Equal(0))
+				Expect(positionWithinSectionBody).To(Equal(0))
+			})
+
+			It("moves back within a section", func() {
+				position, positionWithinSectionBody := page.MoveToPreviousBodyPart(bodyChopper, 1, 5)
+				Expect(position).To(Equal(1))
+				Expect(positionWithinSectionBody).To(Equal(0))
+			})
+
+			It("moves to the last body part of the previous section", func() {
+				position, positionWithinSectionBody := page.MoveToPreviousBodyPart(bodyChopper, 2, 0)
+				Expect(position).To(Equal(1))
+				Expect(positionWithinSectionBody).To(Equal(5))
+				Expect(bodyChopper.FetchBodyPart(page.TextForPosition(position), positionWithinSectionBody)).To(Equal("dy A"))
+			})
+		})
 	})
 
 	Context("English", func() {